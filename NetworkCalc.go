@@ -18,6 +18,9 @@ package main
 import (
 	"fmt"
 	"go-Telegram-NetworkCalculator-bot/config"
+	"go-Telegram-NetworkCalculator-bot/geoip"
+	"go-Telegram-NetworkCalculator-bot/ipam"
+	"go-Telegram-NetworkCalculator-bot/prefixsets"
 	"go-Telegram-NetworkCalculator-bot/roles"
 	"go-Telegram-NetworkCalculator-bot/telegram"
 	"runtime"
@@ -31,21 +34,64 @@ func main() {
 		panic("Unable to start roles.")
 	}
 
+	poolsDb, err := ipam.NewPools(config.PoolsFile)
+
+	if err != nil {
+		rolesDb.Close()
+		fmt.Println(err)
+		panic("Unable to start pools.")
+	}
+
+	reservationsDb, err := ipam.NewReservations(config.ReservationsFile)
+
+	if err != nil {
+		rolesDb.Close()
+		poolsDb.Close()
+		fmt.Println(err)
+		panic("Unable to start reservations.")
+	}
+
+	prefixSetsDb, err := prefixsets.NewSets(config.PrefixSetsFile)
+
+	if err != nil {
+		rolesDb.Close()
+		poolsDb.Close()
+		reservationsDb.Close()
+		fmt.Println(err)
+		panic("Unable to start prefix sets.")
+	}
+
+	// The GeoIP and ASN databases are optional, the missing ones only disable their commands
+	geoDb, geoErrs := geoip.NewDatabases(config.GeoDatabase, config.AsnDatabase, config.AsnTsvDatabase)
+	for _, geoErr := range geoErrs {
+		fmt.Println(geoErr)
+	}
+
 	// Configure all parameters and run goroutines
-	networkBot, err := telegram.NewTelegramBot(config.Token, rolesDb)
+	networkBot, err := telegram.NewTelegramBot(config.Token, rolesDb, poolsDb, reservationsDb, geoDb, prefixSetsDb)
 
 	if err != nil {
 		rolesDb.Close()
+		poolsDb.Close()
+		reservationsDb.Close()
+		geoDb.Close()
+		prefixSetsDb.Close()
 		fmt.Println(err)
 		panic("Unable to configure Telegram bot from token.")
 	}
 
 	if err = networkBot.ManageUpdates(networkBot.HandleUpdate); err != nil {
 		rolesDb.Close()
+		poolsDb.Close()
+		reservationsDb.Close()
+		geoDb.Close()
+		prefixSetsDb.Close()
 		fmt.Println(err)
 		panic("Unable to start Telegram polling routine.")
 	}
 
+	networkBot.ManageReservations()
+
 	// Terminate main goroutine but keep running the others
 	runtime.Goexit()
 }
@@ -16,7 +16,31 @@
 package config
 
 const (
-	Token     = "" 				// Bot token - Example: "1234567890:AAA-sdfsdfsdfjhghsdhjfhjdsfjdfjhjjh"
-	RolesFile = "roles.json"    // JSON file that will contain the roles
-	LogChat   = 0               // Log Chat ID - Example: -1001111111000 (0 to disable)
+	Token     = ""           // Bot token - Example: "1234567890:AAA-sdfsdfsdfjhghsdhjfhjdsfjdfjhjjh"
+	RolesFile = "roles.json" // JSON file that will contain the roles
+	LogChat   = 0            // Log Chat ID - Example: -1001111111000 (0 to disable)
+	PoolsFile = "pools.json" // JSON file that will contain the IPAM pools of every chat
+
+	ReservationsFile         = "reservations.json" // JSON file that will contain the reserved addresses
+	ReservationsNoticeHours  = 24                  // Hours before the expiry of a reservation when its owner is warned
+	ReservationsCheckMinutes = 10                  // Minutes between two checks of the expired reservations
+
+	RouterTemplatesDir = "templates" // Directory with custom <vendor>.tmpl router templates for /ifconfig ("" to disable)
+	DocumentMaxSize    = 1 << 20     // Maximum size in bytes of the documents read by the bot
+	DocumentTimeout    = 30          // Seconds before the download of a document is cancelled
+
+	GeoDatabase    = "" // MaxMind GeoLite2-City or GeoLite2-Country .mmdb file for /geo ("" to disable)
+	AsnDatabase    = "" // MaxMind GeoLite2-ASN .mmdb file for /asn ("" to use only AsnTsvDatabase)
+	AsnTsvDatabase = "" // iptoasn.com ip2asn-combined.tsv file for /asn ("" to use only AsnDatabase)
+
+	PrefixSetsFile = "prefixsets.json" // JSON file that will contain the named prefix sets of /inset
+	PrefixSetsDir  = "datasets"        // Directory of the files that admins load as prefix sets with /setload
+
+	RdapServer       = "https://rdap.org/" // Base URL of the RDAP server of /rdap, a bootstrap server or a registry mirror
+	WhoisServer      = "whois.iana.org:43" // WHOIS server used when the RDAP one fails, its referrals are followed ("" to disable)
+	RdapTimeout      = 10                  // Seconds before an RDAP or WHOIS query is cancelled
+	RdapCacheMinutes = 60                  // Minutes an answer of /rdap is kept in cache
+
+	DnsResolver = "1.1.1.1:53" // Recursive resolver ("host:port") queried by /dig
+	DnsTimeout  = 5            // Seconds before a query of /dig is cancelled
 )
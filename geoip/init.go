@@ -0,0 +1,235 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package geoip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+)
+
+// Errors returned when the database of a lookup isn't loaded
+var (
+	ErrNoGeoDatabase = errors.New("no GeoIP database is loaded")
+	ErrNoAsnDatabase = errors.New("no ASN database is loaded")
+)
+
+// GeoRecord is the location of an address
+type GeoRecord struct {
+	Network        string
+	Continent      string
+	Country        string
+	CountryCode    string
+	Region         string
+	City           string
+	Postal         string
+	Latitude       float64
+	Longitude      float64
+	AccuracyRadius uint64 // Kilometers, 0 if the database has no location
+	TimeZone       string
+}
+
+// AsnRecord is the autonomous system announcing an address
+type AsnRecord struct {
+	Network      string
+	Number       uint64
+	Organization string
+	Country      string // Only in the TSV databases
+}
+
+// Databases contains the local GeoIP and ASN databases, the missing ones disable their lookups
+type Databases struct {
+	geoFile    string
+	asnFile    string
+	asnTsvFile string
+	geo        *Reader
+	asn        *Reader
+	asnTsv     *AsnTable
+	mutex      *sync.RWMutex
+}
+
+// Create a new databases instance from a MaxMind City or Country file, a MaxMind ASN file and an iptoasn TSV file.
+// Empty filenames are skipped, the databases that can't be loaded are returned as errors and stay disabled.
+func NewDatabases(geoFile string, asnFile string, asnTsvFile string) (*Databases, []error) {
+	databases := &Databases{geoFile: geoFile, asnFile: asnFile, asnTsvFile: asnTsvFile, mutex: &sync.RWMutex{}}
+
+	return databases, databases.Reload()
+}
+
+// Reload reads again the database files, so they can be updated without restarting the bot.
+// A database that can't be loaded keeps its previous version
+func (databases *Databases) Reload() []error {
+	errs := make([]error, 0)
+
+	// The files are read without holding the mutex, so the lookups continue meanwhile
+	var geo, asn *Reader
+	var asnTsv *AsnTable
+	var err error
+	if databases.geoFile != "" {
+		if geo, err = OpenReader(databases.geoFile); err != nil {
+			errs = append(errs, fmt.Errorf("GeoIP database: %v", err))
+		}
+	}
+	if databases.asnFile != "" {
+		if asn, err = OpenReader(databases.asnFile); err != nil {
+			errs = append(errs, fmt.Errorf("ASN database: %v", err))
+		}
+	}
+	if databases.asnTsvFile != "" {
+		if asnTsv, err = LoadAsnTable(databases.asnTsvFile); err != nil {
+			errs = append(errs, fmt.Errorf("ASN TSV database: %v", err))
+		}
+	}
+
+	databases.mutex.Lock()
+	defer databases.mutex.Unlock()
+
+	if geo != nil {
+		databases.geo = geo
+	}
+	if asn != nil {
+		databases.asn = asn
+	}
+	if asnTsv != nil {
+		databases.asnTsv = asnTsv
+	}
+
+	return errs
+}
+
+// Close the databases
+func (databases *Databases) Close() {
+	databases.mutex.Lock()
+	defer databases.mutex.Unlock()
+
+	databases.geo = nil
+	databases.asn = nil
+	databases.asnTsv = nil
+}
+
+// Status returns the loaded databases, like "GeoIP: GeoLite2-City, ASN: GeoLite2-ASN"
+func (databases *Databases) Status() string {
+	databases.mutex.RLock()
+	defer databases.mutex.RUnlock()
+
+	status := "GeoIP: disabled"
+	if databases.geo != nil {
+		status = "GeoIP: " + databases.geo.DatabaseType
+	}
+
+	switch {
+	case databases.asn != nil && databases.asnTsv != nil:
+		status += ", ASN: " + databases.asn.DatabaseType + " and TSV"
+	case databases.asn != nil:
+		status += ", ASN: " + databases.asn.DatabaseType
+	case databases.asnTsv != nil:
+		status += ", ASN: TSV"
+	default:
+		status += ", ASN: disabled"
+	}
+
+	return status
+}
+
+// Get a value of a MaxMind record from its path of keys
+func field(record interface{}, path ...string) interface{} {
+	for _, key := range path {
+		values, ok := record.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		record = values[key]
+	}
+
+	return record
+}
+
+// Get the English name of a MaxMind record
+func englishName(record interface{}) string {
+	name, _ := field(record, "names", "en").(string)
+	return name
+}
+
+// Geo returns the location of an address, found is false if the database has no record of it
+func (databases *Databases) Geo(ip net.IP) (GeoRecord, bool, error) {
+	databases.mutex.RLock()
+	reader := databases.geo
+	databases.mutex.RUnlock()
+
+	if reader == nil {
+		return GeoRecord{}, false, ErrNoGeoDatabase
+	}
+
+	record, network, found, err := reader.Lookup(ip)
+	if !found || err != nil {
+		return GeoRecord{}, false, err
+	}
+
+	geo := GeoRecord{Network: network.String()}
+	geo.Continent = englishName(field(record, "continent"))
+	geo.Country = englishName(field(record, "country"))
+	geo.CountryCode, _ = field(record, "country", "iso_code").(string)
+	geo.City = englishName(field(record, "city"))
+	geo.Postal, _ = field(record, "postal", "code").(string)
+	geo.Latitude, _ = field(record, "location", "latitude").(float64)
+	geo.Longitude, _ = field(record, "location", "longitude").(float64)
+	geo.AccuracyRadius, _ = field(record, "location", "accuracy_radius").(uint64)
+	geo.TimeZone, _ = field(record, "location", "time_zone").(string)
+	if subdivisions, ok := field(record, "subdivisions").([]interface{}); ok && len(subdivisions) > 0 {
+		geo.Region = englishName(subdivisions[0])
+	}
+
+	// Anonymous and satellite providers have no country, only the registered one
+	if geo.Country == "" {
+		geo.Country = englishName(field(record, "registered_country"))
+		geo.CountryCode, _ = field(record, "registered_country", "iso_code").(string)
+	}
+
+	return geo, true, nil
+}
+
+// Asn returns the autonomous system of an address from the MaxMind database, or from the TSV one if it has no record
+func (databases *Databases) Asn(ip net.IP) (AsnRecord, bool, error) {
+	databases.mutex.RLock()
+	reader, table := databases.asn, databases.asnTsv
+	databases.mutex.RUnlock()
+
+	if reader == nil && table == nil {
+		return AsnRecord{}, false, ErrNoAsnDatabase
+	}
+
+	if reader != nil {
+		record, network, found, err := reader.Lookup(ip)
+		if err != nil {
+			return AsnRecord{}, false, err
+		}
+
+		if found {
+			asn := AsnRecord{Network: network.String()}
+			asn.Number, _ = field(record, "autonomous_system_number").(uint64)
+			asn.Organization, _ = field(record, "autonomous_system_organization").(string)
+			return asn, true, nil
+		}
+	}
+
+	if table != nil {
+		asn, found := table.Lookup(ip)
+		return asn, found, nil
+	}
+
+	return AsnRecord{}, false, nil
+}
@@ -0,0 +1,309 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"net"
+)
+
+// Marker written before the metadata at the end of a MaxMind DB file
+var metadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// Types of the values of the MaxMind DB data section
+const (
+	typeExtended = iota
+	typePointer
+	typeString
+	typeDouble
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeArray
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat
+)
+
+// Maximum nesting of the maps, arrays and pointers of a value, a corrupted file could otherwise loop forever
+const maxDecodeDepth = 32
+
+// Reader searches the records of a MaxMind DB (.mmdb) file, like the GeoLite2 ones. The whole file is kept in memory
+type Reader struct {
+	DatabaseType string
+	BuildEpoch   uint64
+	buffer       []byte
+	data         []byte // Data section
+	nodeCount    uint64
+	recordSize   uint64
+	ipVersion    uint64
+	ipv4Start    uint64 // Node of the IPv4 addresses in the IPv6 trees (::/96)
+	ipv4Depth    int
+}
+
+// OpenReader reads a MaxMind DB file
+func OpenReader(filename string) (*Reader, error) {
+	buffer, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	start := bytes.LastIndex(buffer, metadataMarker)
+	if start < 0 {
+		return nil, fmt.Errorf("%s isn't a MaxMind DB file", filename)
+	}
+
+	metadataValue, _, err := decode(buffer[start+len(metadataMarker):], 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metadata in %s: %v", filename, err)
+	}
+	metadata, ok := metadataValue.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid metadata in %s", filename)
+	}
+
+	reader := &Reader{buffer: buffer}
+	reader.DatabaseType, _ = metadata["database_type"].(string)
+	reader.BuildEpoch, _ = metadata["build_epoch"].(uint64)
+	reader.nodeCount, _ = metadata["node_count"].(uint64)
+	reader.recordSize, _ = metadata["record_size"].(uint64)
+	reader.ipVersion, _ = metadata["ip_version"].(uint64)
+
+	if reader.recordSize != 24 && reader.recordSize != 28 && reader.recordSize != 32 {
+		return nil, fmt.Errorf("unsupported record size %d in %s", reader.recordSize, filename)
+	}
+
+	// The data section starts after the search tree and 16 zero bytes
+	treeSize := reader.nodeCount * reader.recordSize / 4
+	if treeSize+16 > uint64(start) {
+		return nil, fmt.Errorf("%s is truncated", filename)
+	}
+	reader.data = buffer[treeSize+16 : start]
+
+	// The IPv4 addresses of the IPv6 trees are in ::/96
+	if reader.ipVersion == 6 {
+		for reader.ipv4Depth < 96 && reader.ipv4Start < reader.nodeCount {
+			reader.ipv4Start = reader.readRecord(reader.ipv4Start, 0)
+			reader.ipv4Depth++
+		}
+	}
+
+	return reader, nil
+}
+
+// readRecord returns the left (bit 0) or right (bit 1) record of a node of the search tree
+func (reader *Reader) readRecord(node uint64, bit int) uint64 {
+	nodeBytes := reader.buffer[node*reader.recordSize/4 : (node+1)*reader.recordSize/4]
+
+	switch reader.recordSize {
+	case 24:
+		return uint64(nodeBytes[bit*3])<<16 | uint64(nodeBytes[bit*3+1])<<8 | uint64(nodeBytes[bit*3+2])
+	case 28:
+		// The middle byte holds the most significant bits of both records
+		if bit == 0 {
+			return uint64(nodeBytes[3]&0xF0)<<20 | uint64(nodeBytes[0])<<16 | uint64(nodeBytes[1])<<8 | uint64(nodeBytes[2])
+		}
+		return uint64(nodeBytes[3]&0x0F)<<24 | uint64(nodeBytes[4])<<16 | uint64(nodeBytes[5])<<8 | uint64(nodeBytes[6])
+	default:
+		return uint64(binary.BigEndian.Uint32(nodeBytes[bit*4 : bit*4+4]))
+	}
+}
+
+// Lookup returns the record of an address with the network it belongs to, found is false if there is no record
+func (reader *Reader) Lookup(ip net.IP) (record interface{}, network *net.IPNet, found bool, err error) {
+	address := ip.To4()
+	node, depth := uint64(0), 0
+	if address != nil && reader.ipVersion == 6 {
+		node, depth = reader.ipv4Start, reader.ipv4Depth
+	} else if address == nil {
+		if reader.ipVersion == 4 {
+			return nil, nil, false, errors.New("the database contains only IPv4 addresses")
+		}
+		address = ip.To16()
+	}
+	start := depth
+
+	bits := len(address) * 8
+	for i := 0; i < bits && node < reader.nodeCount; i++ {
+		node = reader.readRecord(node, int(address[i/8]>>(7-uint(i%8)))&1)
+		depth++
+	}
+
+	// The node count means no record, bigger values point to the data section
+	if node <= reader.nodeCount {
+		return nil, nil, false, nil
+	}
+
+	record, _, err = decode(reader.data, node-reader.nodeCount-16, 0)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	length := depth - start
+	network = &net.IPNet{IP: address.Mask(net.CIDRMask(length, bits)), Mask: net.CIDRMask(length, bits)}
+	return record, network, true, nil
+}
+
+// decode reads the value at an offset of a data section, returning it with the offset of the next value. depth is
+// the nesting of the value, the values deeper than maxDecodeDepth are refused
+func decode(data []byte, offset uint64, depth int) (interface{}, uint64, error) {
+	if depth > maxDecodeDepth {
+		return nil, 0, errors.New("values nested too deeply")
+	}
+	if offset >= uint64(len(data)) {
+		return nil, 0, errors.New("offset outside of the data section")
+	}
+
+	control := data[offset]
+	offset++
+	kind := int(control >> 5)
+
+	// Pointers to another value of the data section
+	if kind == typePointer {
+		size := int(control>>3) & 0x3
+		if offset+uint64(size)+1 > uint64(len(data)) {
+			return nil, 0, errors.New("truncated pointer")
+		}
+
+		var pointer uint64
+		if size < 3 {
+			pointer = uint64(control & 0x7)
+		}
+		for _, value := range data[offset : offset+uint64(size)+1] {
+			pointer = pointer<<8 | uint64(value)
+		}
+		pointer += []uint64{0, 2048, 526336, 0}[size]
+
+		// A pointer can't point to another pointer
+		if pointer < uint64(len(data)) && int(data[pointer]>>5) == typePointer {
+			return nil, 0, errors.New("pointer to a pointer")
+		}
+
+		value, _, err := decode(data, pointer, depth+1)
+		return value, offset + uint64(size) + 1, err
+	}
+
+	if kind == typeExtended {
+		if offset >= uint64(len(data)) {
+			return nil, 0, errors.New("truncated extended type")
+		}
+		kind = 7 + int(data[offset])
+		offset++
+	}
+
+	// Sizes from 29 are written in the following bytes
+	size := uint64(control & 0x1f)
+	if size >= 29 {
+		extra := size - 28
+		if offset+extra > uint64(len(data)) {
+			return nil, 0, errors.New("truncated size")
+		}
+		var value uint64
+		for _, b := range data[offset : offset+extra] {
+			value = value<<8 | uint64(b)
+		}
+		size = []uint64{29, 285, 65821}[extra-1] + value
+		offset += extra
+	}
+
+	// Maps, arrays and booleans have no payload, their size is the quantity of items or the value. Every item takes at
+	// least a byte, so a bigger quantity is corrupted
+	if (kind == typeMap || kind == typeArray) && size > uint64(len(data))-offset {
+		return nil, 0, errors.New("truncated container")
+	}
+
+	switch kind {
+	case typeMap:
+		values := make(map[string]interface{}, size)
+		for i := uint64(0); i < size; i++ {
+			key, next, err := decode(data, offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			value, next, err := decode(data, next, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			keyString, _ := key.(string)
+			values[keyString] = value
+			offset = next
+		}
+		return values, offset, nil
+	case typeArray:
+		values := make([]interface{}, 0, size)
+		for i := uint64(0); i < size; i++ {
+			value, next, err := decode(data, offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			values = append(values, value)
+			offset = next
+		}
+		return values, offset, nil
+	case typeBool:
+		return size != 0, offset, nil
+	}
+
+	if offset+size > uint64(len(data)) {
+		return nil, 0, errors.New("truncated value")
+	}
+	payload := data[offset : offset+size]
+	offset += size
+
+	switch kind {
+	case typeString:
+		return string(payload), offset, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, errors.New("invalid double size")
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(payload)), offset, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, errors.New("invalid float size")
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(payload))), offset, nil
+	case typeBytes:
+		return payload, offset, nil
+	case typeUint16, typeUint32, typeUint64:
+		var value uint64
+		for _, b := range payload {
+			value = value<<8 | uint64(b)
+		}
+		return value, offset, nil
+	case typeInt32:
+		var value uint32
+		for _, b := range payload {
+			value = value<<8 | uint32(b)
+		}
+		return int64(int32(value)), offset, nil
+	case typeUint128:
+		return new(big.Int).SetBytes(payload), offset, nil
+	}
+
+	return nil, 0, fmt.Errorf("unknown data type %d", kind)
+}
@@ -0,0 +1,98 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package geoip
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Range of addresses announced by an autonomous system, with the addresses in IPv6 form so they can be compared
+type asnRange struct {
+	first  net.IP
+	last   net.IP
+	record AsnRecord
+}
+
+// AsnTable contains the ranges of an iptoasn.com TSV file (ip2asn-v4.tsv, ip2asn-v6.tsv or ip2asn-combined.tsv)
+type AsnTable struct {
+	ranges []asnRange
+}
+
+// LoadAsnTable reads a TSV file with the "first	last	number	country	description" rows of iptoasn.com.
+// The ranges of AS 0 aren't routed and are skipped
+func LoadAsnTable(filename string) (*AsnTable, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	table := &AsnTable{ranges: make([]asnRange, 0)}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 5 {
+			continue
+		}
+
+		first, last := net.ParseIP(fields[0]), net.ParseIP(fields[1])
+		number, err := strconv.ParseUint(fields[2], 10, 32)
+		if first == nil || last == nil || err != nil {
+			return nil, fmt.Errorf("invalid row at line %d of %s", line, filename)
+		}
+		if number == 0 {
+			continue
+		}
+
+		table.ranges = append(table.ranges, asnRange{first.To16(), last.To16(), AsnRecord{
+			Network:      fields[0] + "-" + fields[1],
+			Number:       number,
+			Organization: fields[4],
+			Country:      fields[3],
+		}})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(table.ranges, func(a, b int) bool {
+		return bytes.Compare(table.ranges[a].first, table.ranges[b].first) < 0
+	})
+
+	return table, nil
+}
+
+// Lookup returns the autonomous system of the range containing an address
+func (table *AsnTable) Lookup(ip net.IP) (AsnRecord, bool) {
+	address := ip.To16()
+
+	// The last range starting before or at the address is the only one that can contain it
+	i := sort.Search(len(table.ranges), func(i int) bool {
+		return bytes.Compare(table.ranges[i].first, address) > 0
+	}) - 1
+	if i < 0 || bytes.Compare(table.ranges[i].last, address) < 0 {
+		return AsnRecord{}, false
+	}
+
+	return table.ranges[i].record, true
+}
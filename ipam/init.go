@@ -0,0 +1,100 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ipam
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// Allocation is a block assigned from a pool
+type Allocation struct {
+	Network     string    `json:"network"`
+	Description string    `json:"description"`
+	Owner       int64     `json:"owner"`
+	Date        time.Time `json:"date"`
+}
+
+// Pool is a named network of a chat, blocks are allocated from it
+type Pool struct {
+	Name        string       `json:"name"`
+	Network     string       `json:"network"`
+	Allocations []Allocation `json:"allocations"`
+}
+
+// Pools contains the pools of every chat
+type Pools struct {
+	Chats    map[int64][]Pool `json:"chats"`
+	filename string
+	mutex    *sync.Mutex
+}
+
+// Create a new pools instance from filename and return Pools pointer. A missing file means no pools.
+func NewPools(filename string) (*Pools, error) {
+	// Instantiate a new pools struct
+	pools := new(Pools)
+	pools.Chats = make(map[int64][]Pool)
+	pools.filename = filename
+	pools.mutex = &sync.Mutex{}
+
+	// Read file to a byte slice
+	poolsFile, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return pools, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode json file to pools struct
+	err = json.Unmarshal(poolsFile, pools)
+	if err != nil {
+		return nil, err
+	}
+
+	return pools, nil
+}
+
+// Close a previously opened pools instance
+func (pools *Pools) Close() {
+	pools.Chats = nil
+	pools.mutex = nil
+	pools.filename = ""
+}
+
+// Write the pools to file, the caller must hold the mutex
+func (pools *Pools) save() error {
+	return writeJSON(pools.filename, pools)
+}
+
+// Write a value as json to file.
+// The json is written to a temporary file first, so a failure never leaves a truncated file.
+func writeJSON(filename string, value interface{}) error {
+	jsonValue, err := json.MarshalIndent(value, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(filename+".tmp", jsonValue, 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(filename+".tmp", filename)
+}
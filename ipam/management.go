@@ -0,0 +1,244 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ipam
+
+import (
+	"errors"
+	"fmt"
+	"go-Telegram-NetworkCalculator-bot/network"
+	"net"
+	"strings"
+	"time"
+)
+
+// Copy the pools of a chat, so they can be changed without touching the saved ones
+func (pools *Pools) copyChat(chatID int64) []Pool {
+	chatPools := make([]Pool, len(pools.Chats[chatID]))
+	for i, pool := range pools.Chats[chatID] {
+		chatPools[i] = pool
+		chatPools[i].Allocations = make([]Allocation, len(pool.Allocations))
+		copy(chatPools[i].Allocations, pool.Allocations)
+	}
+
+	return chatPools
+}
+
+// Replace the pools of a chat and write them to file, restoring the old ones if there is an error
+func (pools *Pools) update(chatID int64, chatPools []Pool) error {
+	oldPools, existed := pools.Chats[chatID]
+
+	if len(chatPools) == 0 {
+		delete(pools.Chats, chatID)
+	} else {
+		pools.Chats[chatID] = chatPools
+	}
+
+	err := pools.save()
+	if err != nil {
+		// Restore the pools because there is an I/O error
+		if existed {
+			pools.Chats[chatID] = oldPools
+		} else {
+			delete(pools.Chats, chatID)
+		}
+	}
+
+	return err
+}
+
+// Search a pool by name, case insensitive. Return index in the chat pools or -1 if not found.
+func findPool(chatPools []Pool, name string) int {
+	for i := range chatPools {
+		if strings.EqualFold(chatPools[i].Name, name) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Blocks allocated from a pool
+func (pool Pool) usedBlocks() ([]network.Block, error) {
+	used := make([]network.Block, 0, len(pool.Allocations))
+	for _, allocation := range pool.Allocations {
+		block, err := network.ParseBlock(allocation.Network)
+		if err != nil {
+			return nil, err
+		}
+		used = append(used, block)
+	}
+
+	return used, nil
+}
+
+// Utilization returns the quantity of allocated addresses and the total addresses of the pool
+func (pool Pool) Utilization() (uint64, uint64) {
+	parent, err := network.ParseBlock(pool.Network)
+	if err != nil {
+		return 0, 0
+	}
+
+	used, _ := pool.usedBlocks()
+	var allocated uint64
+	for _, block := range used {
+		allocated += block.Size()
+	}
+
+	return allocated, parent.Size()
+}
+
+// GetPools returns a copy of the pools of a chat
+func (pools *Pools) GetPools(chatID int64) []Pool {
+	pools.mutex.Lock()
+	defer pools.mutex.Unlock()
+
+	return pools.copyChat(chatID)
+}
+
+// Add a pool to a chat
+func (pools *Pools) AddPool(chatID int64, name string, cidr string) error {
+	block, err := network.ParseBlock(cidr)
+	if err != nil {
+		return err
+	}
+
+	pools.mutex.Lock()
+	defer pools.mutex.Unlock()
+
+	chatPools := pools.copyChat(chatID)
+	if findPool(chatPools, name) >= 0 {
+		return fmt.Errorf("a pool named %s already exists", name)
+	}
+
+	// Pools of the same chat can't overlap, or the same address could be allocated twice
+	for _, pool := range chatPools {
+		poolBlock, err := network.ParseBlock(pool.Network)
+		if err == nil && poolBlock.Overlaps(block) {
+			return fmt.Errorf("%s overlaps the pool %s (%s)", block, pool.Name, pool.Network)
+		}
+	}
+
+	chatPools = append(chatPools, Pool{Name: name, Network: block.String(), Allocations: make([]Allocation, 0)})
+
+	return pools.update(chatID, chatPools)
+}
+
+// Remove an empty pool from a chat
+func (pools *Pools) RemovePool(chatID int64, name string) error {
+	pools.mutex.Lock()
+	defer pools.mutex.Unlock()
+
+	chatPools := pools.copyChat(chatID)
+	poolIndex := findPool(chatPools, name)
+	if poolIndex < 0 {
+		return fmt.Errorf("there is no pool named %s", name)
+	}
+
+	if len(chatPools[poolIndex].Allocations) > 0 {
+		return fmt.Errorf("the pool %s still has %d allocations, release them first", name, len(chatPools[poolIndex].Allocations))
+	}
+
+	chatPools = append(chatPools[:poolIndex], chatPools[poolIndex+1:]...)
+
+	return pools.update(chatID, chatPools)
+}
+
+// Allocate the next free aligned block with the given prefix from a pool
+func (pools *Pools) Allocate(chatID int64, name string, prefix uint8, description string, owner int64) (Allocation, error) {
+	pools.mutex.Lock()
+	defer pools.mutex.Unlock()
+
+	chatPools := pools.copyChat(chatID)
+	poolIndex := findPool(chatPools, name)
+	if poolIndex < 0 {
+		return Allocation{}, fmt.Errorf("there is no pool named %s", name)
+	}
+	pool := &chatPools[poolIndex]
+
+	parent, err := network.ParseBlock(pool.Network)
+	if err != nil {
+		return Allocation{}, err
+	}
+
+	used, err := pool.usedBlocks()
+	if err != nil {
+		return Allocation{}, err
+	}
+
+	block, err := network.NextFreeBlock(parent, used, prefix)
+	if err != nil {
+		return Allocation{}, err
+	}
+
+	allocation := Allocation{block.String(), description, owner, time.Now().UTC()}
+	pool.Allocations = append(pool.Allocations, allocation)
+
+	return allocation, pools.update(chatID, chatPools)
+}
+
+// Release an allocated block of a pool
+func (pools *Pools) Release(chatID int64, name string, cidr string) error {
+	block, err := network.ParseBlock(cidr)
+	if err != nil {
+		return err
+	}
+
+	pools.mutex.Lock()
+	defer pools.mutex.Unlock()
+
+	chatPools := pools.copyChat(chatID)
+	poolIndex := findPool(chatPools, name)
+	if poolIndex < 0 {
+		return fmt.Errorf("there is no pool named %s", name)
+	}
+	pool := &chatPools[poolIndex]
+
+	for i := range pool.Allocations {
+		if pool.Allocations[i].Network == block.String() {
+			pool.Allocations = append(pool.Allocations[:i], pool.Allocations[i+1:]...)
+			return pools.update(chatID, chatPools)
+		}
+	}
+
+	return errors.New(block.String() + " isn't allocated in " + pool.Name)
+}
+
+// Lookup returns the pool of a chat containing an address and the allocation containing it, nil if it isn't allocated
+func (pools *Pools) Lookup(chatID int64, address net.IP) (Pool, *Allocation, bool) {
+	address = address.To4()
+	if address == nil {
+		return Pool{}, nil, false
+	}
+	host := network.Block{Network: address, Netmask: network.CidrToMask(32)}
+
+	for _, pool := range pools.GetPools(chatID) {
+		poolBlock, err := network.ParseBlock(pool.Network)
+		if err != nil || !poolBlock.Contains(host) {
+			continue
+		}
+
+		for i := range pool.Allocations {
+			block, err := network.ParseBlock(pool.Allocations[i].Network)
+			if err == nil && block.Contains(host) {
+				return pool, &pool.Allocations[i], true
+			}
+		}
+
+		return pool, nil, true
+	}
+
+	return Pool{}, nil, false
+}
@@ -0,0 +1,233 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ipam
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-Telegram-NetworkCalculator-bot/network"
+	"io/ioutil"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// Reservation is a single address reserved by a user until its expiry
+type Reservation struct {
+	ChatID    int64     `json:"chat"`
+	Address   string    `json:"address"`
+	Owner     int64     `json:"owner"`
+	OwnerName string    `json:"owner_name"`
+	Purpose   string    `json:"purpose"`
+	Expiry    time.Time `json:"expiry"`
+	Notified  bool      `json:"notified"` // True if the owner has been warned of the expiry
+}
+
+// Reservations contains the reserved addresses of every chat
+type Reservations struct {
+	List     []Reservation `json:"reservations"`
+	filename string
+	mutex    *sync.Mutex
+}
+
+// Create a new reservations instance from filename and return Reservations pointer. A missing file means no reservations.
+func NewReservations(filename string) (*Reservations, error) {
+	// Instantiate a new reservations struct
+	reservations := new(Reservations)
+	reservations.List = make([]Reservation, 0)
+	reservations.filename = filename
+	reservations.mutex = &sync.Mutex{}
+
+	// Read file to a byte slice
+	reservationsFile, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return reservations, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode json file to reservations struct
+	err = json.Unmarshal(reservationsFile, reservations)
+	if err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
+
+// Close a previously opened reservations instance
+func (reservations *Reservations) Close() {
+	reservations.List = nil
+	reservations.mutex = nil
+	reservations.filename = ""
+}
+
+// Replace the reservations and write them to file, restoring the old ones if there is an error. The caller must hold the mutex.
+func (reservations *Reservations) update(list []Reservation) error {
+	oldList := reservations.List
+	reservations.List = list
+
+	err := writeJSON(reservations.filename, reservations)
+	if err != nil {
+		// Restore the reservations because there is an I/O error
+		reservations.List = oldList
+	}
+
+	return err
+}
+
+// Search the reservation of an address in a chat. Return index in the list or -1 if not found.
+func (reservations *Reservations) find(chatID int64, address string) int {
+	for i := range reservations.List {
+		if reservations.List[i].ChatID == chatID && reservations.List[i].Address == address {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Find returns the reservation of an address in a chat
+func (reservations *Reservations) Find(chatID int64, address string) (Reservation, bool) {
+	reservations.mutex.Lock()
+	defer reservations.mutex.Unlock()
+
+	if i := reservations.find(chatID, address); i >= 0 {
+		return reservations.List[i], true
+	}
+
+	return Reservation{}, false
+}
+
+// InBlock returns the reservations of a chat inside a block
+func (reservations *Reservations) InBlock(chatID int64, block network.Block) []Reservation {
+	reservations.mutex.Lock()
+	defer reservations.mutex.Unlock()
+
+	found := make([]Reservation, 0)
+	for _, reservation := range reservations.List {
+		address := net.ParseIP(reservation.Address).To4()
+		if reservation.ChatID == chatID && address != nil && block.Contains(network.Block{Network: address, Netmask: network.CidrToMask(32)}) {
+			found = append(found, reservation)
+		}
+	}
+
+	return found
+}
+
+// Reserve an address, it must not be already reserved in the same chat unless by the same owner, who renews the
+// reservation with the new expiry and purpose
+func (reservations *Reservations) Reserve(reservation Reservation) error {
+	address := net.ParseIP(reservation.Address).To4()
+	if address == nil {
+		return fmt.Errorf("invalid address %s", reservation.Address)
+	}
+	reservation.Address = address.String()
+
+	if !reservation.Expiry.After(time.Now()) {
+		return errors.New("the expiry must be in the future")
+	}
+
+	reservations.mutex.Lock()
+	defer reservations.mutex.Unlock()
+
+	if i := reservations.find(reservation.ChatID, reservation.Address); i >= 0 {
+		if reservations.List[i].Owner != reservation.Owner {
+			return fmt.Errorf("%s is already reserved by %s until %s", reservation.Address, reservations.List[i].OwnerName, reservations.List[i].Expiry.Format("2006-01-02"))
+		}
+
+		// The owner is warned again before the new expiry
+		list := make([]Reservation, len(reservations.List))
+		copy(list, reservations.List)
+		reservation.Notified = false
+		list[i] = reservation
+
+		return reservations.update(list)
+	}
+
+	list := make([]Reservation, len(reservations.List), len(reservations.List)+1)
+	copy(list, reservations.List)
+
+	return reservations.update(append(list, reservation))
+}
+
+// Unreserve releases the reservation of an address, only its owner or an admin (force) can do it
+func (reservations *Reservations) Unreserve(chatID int64, address string, requester int64, force bool) error {
+	reservations.mutex.Lock()
+	defer reservations.mutex.Unlock()
+
+	i := reservations.find(chatID, address)
+	if i < 0 {
+		return fmt.Errorf("%s isn't reserved", address)
+	}
+
+	if reservations.List[i].Owner != requester && !force {
+		return errors.New("only the owner can release this reservation")
+	}
+
+	list := make([]Reservation, 0, len(reservations.List)-1)
+	list = append(list, reservations.List[:i]...)
+	list = append(list, reservations.List[i+1:]...)
+
+	return reservations.update(list)
+}
+
+// Expiring marks as notified and returns the reservations expiring before the deadline whose owner hasn't been warned yet
+func (reservations *Reservations) Expiring(deadline time.Time) ([]Reservation, error) {
+	reservations.mutex.Lock()
+	defer reservations.mutex.Unlock()
+
+	expiring := make([]Reservation, 0)
+	list := make([]Reservation, len(reservations.List))
+	copy(list, reservations.List)
+	for i := range list {
+		if !list[i].Notified && list[i].Expiry.Before(deadline) {
+			list[i].Notified = true
+			expiring = append(expiring, list[i])
+		}
+	}
+
+	if len(expiring) == 0 {
+		return expiring, nil
+	}
+
+	return expiring, reservations.update(list)
+}
+
+// ReleaseExpired removes and returns the reservations expired before now
+func (reservations *Reservations) ReleaseExpired(now time.Time) ([]Reservation, error) {
+	reservations.mutex.Lock()
+	defer reservations.mutex.Unlock()
+
+	expired := make([]Reservation, 0)
+	list := make([]Reservation, 0, len(reservations.List))
+	for _, reservation := range reservations.List {
+		if reservation.Expiry.Before(now) {
+			expired = append(expired, reservation)
+		} else {
+			list = append(list, reservation)
+		}
+	}
+
+	if len(expired) == 0 {
+		return expired, nil
+	}
+
+	return expired, reservations.update(list)
+}
@@ -0,0 +1,214 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Multipliers of the unit prefixes, decimal (SI) and binary (IEC), by lowercase prefix
+var unitPrefixes = map[string]float64{
+	"":   1,
+	"k":  1e3,
+	"m":  1e6,
+	"g":  1e9,
+	"t":  1e12,
+	"p":  1e15,
+	"ki": 1 << 10,
+	"mi": 1 << 20,
+	"gi": 1 << 30,
+	"ti": 1 << 40,
+	"pi": 1 << 50,
+}
+
+// A number followed by its unit, like "1.5 GB", "100Mbps" or "700MiB"
+var quantityPattern = regexp.MustCompile(`^(\d+(?:\.\d*)?|\.\d+)\s*([A-Za-z/]*)$`)
+
+// Largest TCP window, with the maximum window scale of 14 (RFC 7323)
+const (
+	maxWindowScale = 14
+	maxTCPWindow   = 65535 << maxWindowScale
+)
+
+// parseQuantity returns a quantity in bits. The unit is made of an optional SI or IEC prefix and of b or bit for bits,
+// B or byte for bytes; without it the quantity is in defaultBits units
+func parseQuantity(text string, defaultBits float64) (float64, error) {
+	match := quantityPattern.FindStringSubmatch(strings.TrimSpace(text))
+	if match == nil {
+		return 0, fmt.Errorf("invalid quantity %s", text)
+	}
+	value, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quantity %s", text)
+	}
+
+	// The last letters tell bits from bytes, the case matters only for "b" and "B"
+	unit, base := match[2], defaultBits
+	lower := strings.ToLower(unit)
+	switch {
+	case strings.HasSuffix(lower, "bits"), strings.HasSuffix(lower, "bit"):
+		unit, base = unit[:strings.LastIndex(lower, "bit")], 1
+	case strings.HasSuffix(lower, "bytes"), strings.HasSuffix(lower, "byte"):
+		unit, base = unit[:strings.LastIndex(lower, "byte")], 8
+	case strings.HasSuffix(unit, "b"):
+		unit, base = unit[:len(unit)-1], 1
+	case strings.HasSuffix(unit, "B"):
+		unit, base = unit[:len(unit)-1], 8
+	}
+
+	multiplier, found := unitPrefixes[strings.ToLower(unit)]
+	if !found {
+		return 0, fmt.Errorf("unknown unit %s", match[2])
+	}
+
+	return value * multiplier * base, nil
+}
+
+// ParseSize returns a size in bits, like "1.5GB", "700 MiB" or "10Gbit". A number alone is in bytes
+func ParseSize(text string) (float64, error) {
+	return parseQuantity(text, 8)
+}
+
+// ParseRate returns a rate in bits per second, like "100Mbps", "1 Gbit/s", "10MB/s" or "1GiB/s". A number alone is in
+// bits per second
+func ParseRate(text string) (float64, error) {
+	lower := strings.ToLower(text)
+	for _, suffix := range []string{"/s", "ps"} {
+		if strings.HasSuffix(lower, suffix) {
+			text = text[:len(text)-len(suffix)]
+			break
+		}
+	}
+
+	return parseQuantity(text, 1)
+}
+
+// ParseSeconds returns a duration in seconds, like "80ms", "1.5s", "2h30m" or "3d". A number alone is in seconds
+func ParseSeconds(text string) (float64, error) {
+	if seconds, err := strconv.ParseFloat(text, 64); err == nil && seconds >= 0 {
+		return seconds, nil
+	}
+
+	// Days aren't supported by time.ParseDuration
+	if days := strings.TrimSuffix(text, "d"); days != text {
+		if value, err := strconv.ParseFloat(days, 64); err == nil && value >= 0 {
+			return value * 86400, nil
+		}
+	}
+
+	duration, err := time.ParseDuration(text)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("invalid duration %s", text)
+	}
+
+	return duration.Seconds(), nil
+}
+
+// formatNumber returns a number rounded to two decimals, without trailing zeros
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64)
+}
+
+// scaleUnit returns a value in the biggest prefix of a unit keeping it at least 1, like 1500 to "1.5 k"
+func scaleUnit(value float64, step float64, prefixes []string) string {
+	i := 0
+	for i < len(prefixes)-1 && value >= step {
+		value /= step
+		i++
+	}
+
+	return formatNumber(value) + " " + prefixes[i]
+}
+
+// FormatSize returns a size in bits as SI bytes, IEC bytes and SI bits, like "1.5 GB (1.4 GiB, 12 Gbit)"
+func FormatSize(bits float64) string {
+	return scaleUnit(bits/8, 1000, []string{"B", "kB", "MB", "GB", "TB", "PB"}) + " (" +
+		scaleUnit(bits/8, 1024, []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}) + ", " +
+		scaleUnit(bits, 1000, []string{"bit", "kbit", "Mbit", "Gbit", "Tbit", "Pbit"}) + ")"
+}
+
+// FormatRate returns a rate in bits per second as SI bits, SI bytes and IEC bytes, like "1 Gbit/s (125 MB/s, 119.21 MiB/s)"
+func FormatRate(bps float64) string {
+	return scaleUnit(bps, 1000, []string{"bit/s", "kbit/s", "Mbit/s", "Gbit/s", "Tbit/s", "Pbit/s"}) + " (" +
+		scaleUnit(bps/8, 1000, []string{"B/s", "kB/s", "MB/s", "GB/s", "TB/s", "PB/s"}) + ", " +
+		scaleUnit(bps/8, 1024, []string{"B/s", "KiB/s", "MiB/s", "GiB/s", "TiB/s", "PiB/s"}) + ")"
+}
+
+// FormatSeconds returns a duration like "2d 3h 4m 5.5s", or in milliseconds if it's shorter than a second
+func FormatSeconds(seconds float64) string {
+	if seconds < 1 {
+		return formatNumber(seconds*1000) + " ms"
+	}
+
+	// Round before splitting the units, so 3599.999 is 1h and not 59m 60s
+	seconds = math.Round(seconds*100) / 100
+
+	parts := make([]string, 0, 4)
+	for _, unit := range []struct {
+		name    string
+		seconds float64
+	}{{"d", 86400}, {"h", 3600}, {"m", 60}} {
+		if seconds >= unit.seconds {
+			parts = append(parts, strconv.FormatFloat(math.Floor(seconds/unit.seconds), 'f', 0, 64)+unit.name)
+			seconds = math.Mod(seconds, unit.seconds)
+		}
+	}
+	if seconds > 0 || len(parts) == 0 {
+		parts = append(parts, formatNumber(seconds)+"s")
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// TransferTime returns the seconds needed to transfer a size in bits at a rate in bits per second
+func TransferTime(bits float64, bps float64) (float64, error) {
+	if bps <= 0 {
+		return 0, errors.New("the rate must be greater than zero")
+	}
+
+	return bits / bps, nil
+}
+
+// RequiredRate returns the rate in bits per second needed to transfer a size in bits within a time in seconds
+func RequiredRate(bits float64, seconds float64) (float64, error) {
+	if seconds <= 0 {
+		return 0, errors.New("the time must be greater than zero")
+	}
+
+	return bits / seconds, nil
+}
+
+// BandwidthDelayProduct returns the bits in flight on a link with a rate in bits per second and a round trip time
+// in seconds, which is also the TCP window needed to fill the link
+func BandwidthDelayProduct(bps float64, rtt float64) float64 {
+	return bps * rtt
+}
+
+// WindowScale returns the TCP window scale (RFC 7323) needed for a window in bytes, false if it's bigger than the
+// largest window
+func WindowScale(window float64) (int, bool) {
+	for scale := 0; scale <= maxWindowScale; scale++ {
+		if float64(uint64(65535)<<uint(scale)) >= window {
+			return scale, true
+		}
+	}
+
+	return maxWindowScale, false
+}
+
+// WindowThroughput returns the highest rate in bits per second of a TCP connection with a window in bytes and a
+// round trip time in seconds
+func WindowThroughput(window float64, rtt float64) (float64, error) {
+	if rtt <= 0 {
+		return 0, errors.New("the round trip time must be greater than zero")
+	}
+	if window > maxTCPWindow {
+		window = maxTCPWindow
+	}
+
+	return window * 8 / rtt, nil
+}
@@ -0,0 +1,231 @@
+package network
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Special purpose IPv4 ranges (RFC 6890) and their scope, the most specific ones first
+var ipv4Scopes = []struct {
+	cidr  string
+	scope string
+}{
+	{"255.255.255.255/32", "limited broadcast"},
+	{"192.0.0.0/24", "protocol assignments"},
+	{"192.0.2.0/24", "documentation"},
+	{"198.51.100.0/24", "documentation"},
+	{"203.0.113.0/24", "documentation"},
+	{"192.88.99.0/24", "6to4 relay anycast"},
+	{"198.18.0.0/15", "benchmarking"},
+	{"169.254.0.0/16", "link-local"},
+	{"172.16.0.0/12", "private"},
+	{"192.168.0.0/16", "private"},
+	{"100.64.0.0/10", "shared address space (CGNAT)"},
+	{"0.0.0.0/8", "this network"},
+	{"10.0.0.0/8", "private"},
+	{"127.0.0.0/8", "loopback"},
+	{"224.0.0.0/4", "multicast"},
+	{"240.0.0.0/4", "reserved"},
+}
+
+// Columns of the bulk calculations
+var BulkHeader = []string{"line", "input", "address", "prefix", "netmask", "wildcard", "network", "broadcast", "host_min", "host_max", "hosts", "class", "scope"}
+
+// BulkRow is a network calculated from a row of a bulk list
+type BulkRow struct {
+	Line  int
+	Input string
+	Info  NetworkInfo
+	Hosts uint64 // Usable hosts, counting the RFC 3021 /31 and the /32 ones
+	Class string
+	Scope string
+}
+
+// BulkError is a row of a bulk list that couldn't be calculated
+type BulkError struct {
+	Line  int
+	Input string
+	Err   error
+}
+
+// AddressClass returns the classful class (A-E) of an IPv4 address
+func AddressClass(ip []uint8) string {
+	switch {
+	case ip[0] < 128:
+		return "A"
+	case ip[0] < 192:
+		return "B"
+	case ip[0] < 224:
+		return "C"
+	case ip[0] < 240:
+		return "D"
+	default:
+		return "E"
+	}
+}
+
+// AddressScope returns the special purpose scope of an IPv4 address, or "public"
+func AddressScope(ip []uint8) string {
+	for _, special := range ipv4Scopes {
+		_, ipNet, _ := net.ParseCIDR(special.cidr)
+		if ipNet.Contains(net.IP(ip)) {
+			return special.scope
+		}
+	}
+
+	return "public"
+}
+
+// parseBulkRow reads an address with its netmask from a row: "a.b.c.d/len", "a.b.c.d mask", "a.b.c.d,len" or a single address
+func parseBulkRow(row string) (NetworkInfo, error) {
+	fields := strings.FieldsFunc(row, func(r rune) bool {
+		return r == ',' || r == ';' || r == '\t' || r == ' ' || r == '/' || r == '"'
+	})
+	if len(fields) == 0 {
+		return NetworkInfo{}, errors.New("empty row")
+	}
+
+	ip := net.ParseIP(fields[0])
+	if ip == nil {
+		return NetworkInfo{}, fmt.Errorf("invalid address %s", fields[0])
+	}
+	if ip.To4() == nil {
+		return NetworkInfo{}, errors.New("only IPv4 is supported")
+	}
+
+	// The second field is the netmask only if it's a number or a dotted mask, otherwise it's a description
+	mask := CidrToMask(32)
+	if len(fields) >= 2 && strings.Trim(fields[1], "0123456789.") == "" {
+		prefix, err := strconv.ParseUint(fields[1], 10, 8)
+		if dotted := net.ParseIP(fields[1]).To4(); dotted != nil {
+			ones, bits := net.IPMask(dotted).Size()
+			prefix, err = uint64(ones), nil
+			if bits == 0 {
+				err = errors.New("non contiguous")
+			}
+		}
+
+		if err != nil || prefix > 32 {
+			return NetworkInfo{}, fmt.Errorf("invalid netmask %s", fields[1])
+		}
+		mask = CidrToMask(uint8(prefix))
+	}
+
+	return CalculateNetwork(ip.To4().String(), ByteArrToStr(mask.Dotted)), nil
+}
+
+// CalculateBulk calculates a network for each row of a text or CSV list. The rows without digits (headers) and the
+// ones starting with # are skipped, the invalid ones are returned with their line number
+func CalculateBulk(text string) ([]BulkRow, []BulkError) {
+	rows := make([]BulkRow, 0)
+	invalid := make([]BulkError, 0)
+
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || !strings.ContainsAny(line, "0123456789") {
+			continue
+		}
+
+		info, err := parseBulkRow(line)
+		if err != nil {
+			invalid = append(invalid, BulkError{i + 1, line, err})
+			continue
+		}
+
+		// CalculateNetwork leaves no hosts in /31 and /32 networks, but they are usable (RFC 3021)
+		hosts := uint64(IPToUint32(info.Broadcast)-IPToUint32(info.Network)) + 1
+		if info.Netmask.Decimal <= 30 {
+			hosts -= 2
+		} else {
+			info.HostMinAddress = info.Network
+			info.HostMaxAddress = info.Broadcast
+		}
+
+		rows = append(rows, BulkRow{i + 1, line, info, hosts, AddressClass(info.Address), AddressScope(info.Address)})
+	}
+
+	return rows, invalid
+}
+
+// Columns returns the fields of the row in the order of BulkHeader
+func (row BulkRow) Columns() []string {
+	return []string{
+		strconv.Itoa(row.Line),
+		row.Input,
+		ByteArrToStr(row.Info.Address),
+		strconv.Itoa(int(row.Info.Netmask.Decimal)),
+		ByteArrToStr(row.Info.Netmask.Dotted),
+		ByteArrToStr(row.Info.Wildcard),
+		ByteArrToStr(row.Info.Network),
+		ByteArrToStr(row.Info.Broadcast),
+		ByteArrToStr(row.Info.HostMinAddress),
+		ByteArrToStr(row.Info.HostMaxAddress),
+		strconv.FormatUint(row.Hosts, 10),
+		row.Class,
+		row.Scope,
+	}
+}
+
+// BulkCSV returns the rows as CSV with a header
+func BulkCSV(rows []BulkRow) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+
+	if err := writer.Write(BulkHeader); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if err := writer.Write(row.Columns()); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+
+	return buffer.Bytes(), writer.Error()
+}
+
+// BulkJSON returns the rows as indented JSON, with the BulkHeader keys
+func BulkJSON(rows []BulkRow) ([]byte, error) {
+	type jsonRow struct {
+		Line      int    `json:"line"`
+		Input     string `json:"input"`
+		Address   string `json:"address"`
+		Prefix    uint8  `json:"prefix"`
+		Netmask   string `json:"netmask"`
+		Wildcard  string `json:"wildcard"`
+		Network   string `json:"network"`
+		Broadcast string `json:"broadcast"`
+		HostMin   string `json:"host_min"`
+		HostMax   string `json:"host_max"`
+		Hosts     uint64 `json:"hosts"`
+		Class     string `json:"class"`
+		Scope     string `json:"scope"`
+	}
+
+	output := make([]jsonRow, 0, len(rows))
+	for _, row := range rows {
+		output = append(output, jsonRow{
+			Line:      row.Line,
+			Input:     row.Input,
+			Address:   ByteArrToStr(row.Info.Address),
+			Prefix:    row.Info.Netmask.Decimal,
+			Netmask:   ByteArrToStr(row.Info.Netmask.Dotted),
+			Wildcard:  ByteArrToStr(row.Info.Wildcard),
+			Network:   ByteArrToStr(row.Info.Network),
+			Broadcast: ByteArrToStr(row.Info.Broadcast),
+			HostMin:   ByteArrToStr(row.Info.HostMinAddress),
+			HostMax:   ByteArrToStr(row.Info.HostMaxAddress),
+			Hosts:     row.Hosts,
+			Class:     row.Class,
+			Scope:     row.Scope,
+		})
+	}
+
+	return json.MarshalIndent(output, "", "  ")
+}
@@ -0,0 +1,149 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// Provider describes the addressing rules of a platform where subnets are created
+type Provider struct {
+	Name          string
+	ReservedStart uint64 // Addresses reserved at the beginning of every subnet (network address included)
+	ReservedEnd   uint64 // Addresses reserved at the end of every subnet (broadcast address included)
+	MinPrefix     uint8  // Shortest prefix allowed for a subnet (biggest subnet)
+	MaxPrefix     uint8  // Longest prefix allowed for a subnet (smallest subnet)
+}
+
+// Standard is a plain network where only the network and broadcast addresses are reserved
+var Standard = Provider{"Standard", 1, 1, 0, 30}
+
+// Cloud is a generic cloud provider, reserving the first four addresses and the broadcast
+var Cloud = Provider{"Cloud", 4, 1, 0, 28}
+
+// Providers contains the cloud providers supported by the planning commands, by lowercase key
+var Providers = map[string]Provider{
+	"aws":   {"AWS", 4, 1, 16, 28},
+	"azure": {"Azure", 4, 1, 2, 29},
+	"gcp":   {"GCP", 2, 2, 4, 29},
+}
+
+// Subnet is a single subnet of a VpcPlan
+type Subnet struct {
+	Tier    string
+	Zone    int
+	Network []uint8
+	Netmask Mask
+	Usable  uint64
+}
+
+// VpcPlan contains the result of the PlanVpc function
+type VpcPlan struct {
+	Provider Provider
+	Network  []uint8
+	Netmask  Mask
+	Zones    int
+	Subnets  []Subnet
+}
+
+// FindProvider returns the provider with the given name, case insensitive
+func FindProvider(name string) (Provider, bool) {
+	provider, found := Providers[strings.ToLower(name)]
+	return provider, found
+}
+
+// Reserved returns the quantity of addresses unusable in every subnet
+func (provider Provider) Reserved() uint64 {
+	return provider.ReservedStart + provider.ReservedEnd
+}
+
+// UsableRange returns the first and last usable addresses of a network and their quantity
+func (provider Provider) UsableRange(networkInfo NetworkInfo) ([]uint8, []uint8, uint64) {
+	size := uint64(1) << (32 - uint64(networkInfo.Netmask.Decimal))
+	if size <= provider.Reserved() {
+		return nil, nil, 0
+	}
+
+	networkNum := IPToUint32(networkInfo.Network)
+	hostMin := Uint32ToIP(networkNum + uint32(provider.ReservedStart))
+	hostMax := Uint32ToIP(networkNum + uint32(size-1-provider.ReservedEnd))
+
+	return hostMin, hostMax, size - provider.Reserved()
+}
+
+// CheckPrefix returns an error if the provider doesn't allow subnets with the given prefix
+func (provider Provider) CheckPrefix(prefix uint8) error {
+	if prefix < provider.MinPrefix {
+		return fmt.Errorf("%s doesn't allow subnets bigger than a /%d", provider.Name, provider.MinPrefix)
+	}
+
+	if prefix > provider.MaxPrefix {
+		return fmt.Errorf("%s doesn't allow subnets smaller than a /%d", provider.Name, provider.MaxPrefix)
+	}
+
+	return nil
+}
+
+// ZoneName returns the letter used for the availability zone with the given index (0 is "a")
+func ZoneName(zone int) string {
+	return string(rune('a' + zone))
+}
+
+// PlanVpc splits a network evenly between every tier in every availability zone
+func PlanVpc(cidr string, zones int, tiers []string, provider Provider) (VpcPlan, error) {
+	var plan VpcPlan
+
+	if zones < 1 || zones > 26 {
+		return plan, errors.New("the availability zones must be between 1 and 26")
+	}
+
+	if len(tiers) == 0 {
+		return plan, errors.New("at least a tier is needed")
+	}
+
+	address, netmask, err := ParseCidr(cidr)
+	if err != nil {
+		return plan, err
+	}
+
+	plan.Provider = provider
+	plan.Netmask = netmask
+	plan.Zones = zones
+	plan.Network = make([]uint8, 4)
+	for i := range address {
+		plan.Network[i] = address[i] & netmask.Dotted[i]
+	}
+
+	// Every subnet gets the same size, so round the subnets quantity to the next power of 2
+	subnetsQuantity := zones * len(tiers)
+	subnetBits := bits.Len(uint(subnetsQuantity - 1))
+	prefix := int(netmask.Decimal) + subnetBits
+	if prefix > 32 {
+		return plan, fmt.Errorf("%d subnets don't fit in a /%d", subnetsQuantity, netmask.Decimal)
+	}
+
+	if err = provider.CheckPrefix(uint8(prefix)); err != nil {
+		return plan, err
+	}
+
+	subnetMask := CidrToMask(uint8(prefix))
+	subnetSize := uint64(1) << (32 - uint64(prefix))
+	networkNum := IPToUint32(plan.Network)
+
+	plan.Subnets = make([]Subnet, 0, subnetsQuantity)
+	for t, tier := range tiers {
+		for zone := 0; zone < zones; zone++ {
+			index := uint64(t*zones + zone)
+			plan.Subnets = append(plan.Subnets, Subnet{
+				Tier:    tier,
+				Zone:    zone,
+				Network: Uint32ToIP(networkNum + uint32(index*subnetSize)),
+				Netmask: subnetMask,
+				Usable:  subnetSize - provider.Reserved(),
+			})
+		}
+	}
+
+	return plan, nil
+}
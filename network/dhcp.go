@@ -0,0 +1,178 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// AddressRange is an inclusive range of IPv4 addresses
+type AddressRange struct {
+	First []uint8
+	Last  []uint8
+}
+
+// DhcpScope contains the result of the PlanDhcpScope function
+type DhcpScope struct {
+	Info    NetworkInfo
+	Gateway []uint8 // nil if the scope has no gateway
+	Dns     [][]uint8
+	Pools   []AddressRange
+}
+
+// String returns the range as "first-last"
+func (addressRange AddressRange) String() string {
+	return ByteArrToStr(addressRange.First) + "-" + ByteArrToStr(addressRange.Last)
+}
+
+// ParseRange parses a "first-last" range or a single address
+func ParseRange(str string) (AddressRange, error) {
+	parts := strings.SplitN(str, "-", 2)
+	if len(parts) == 1 {
+		parts = append(parts, parts[0])
+	}
+
+	first := net.ParseIP(parts[0]).To4()
+	last := net.ParseIP(parts[1]).To4()
+	if first == nil || last == nil {
+		return AddressRange{}, fmt.Errorf("invalid range %s", str)
+	}
+
+	if IPToUint32(first) > IPToUint32(last) {
+		return AddressRange{}, fmt.Errorf("the range %s ends before it starts", str)
+	}
+
+	return AddressRange{[]uint8(first), []uint8(last)}, nil
+}
+
+// PlanDhcpScope builds a DHCP scope for a network, the gateway can be "first", "last", "none" or an address of the network
+func PlanDhcpScope(cidr string, gateway string, reserved []string, dns []string) (DhcpScope, error) {
+	var scope DhcpScope
+
+	address, netmask, err := ParseCidr(cidr)
+	if err != nil {
+		return scope, err
+	}
+
+	if netmask.Decimal > 30 {
+		return scope, errors.New("the network is too small for a DHCP scope")
+	}
+
+	scope.Info = CalculateNetwork(ByteArrToStr(address), ByteArrToStr(netmask.Dotted))
+	hostMin := IPToUint32(scope.Info.HostMinAddress)
+	hostMax := IPToUint32(scope.Info.HostMaxAddress)
+
+	// Every excluded address range, as numeric values
+	excluded := make([][2]uint32, 0)
+
+	switch strings.ToLower(gateway) {
+	case "", "first":
+		scope.Gateway = scope.Info.HostMinAddress
+	case "last":
+		scope.Gateway = scope.Info.HostMaxAddress
+	case "none":
+		scope.Gateway = nil
+	default:
+		scope.Gateway = net.ParseIP(gateway).To4()
+		if scope.Gateway == nil {
+			return scope, fmt.Errorf("invalid gateway %s", gateway)
+		}
+
+		if IPToUint32(scope.Gateway) < hostMin || IPToUint32(scope.Gateway) > hostMax {
+			return scope, fmt.Errorf("the gateway %s isn't a usable address of the network", gateway)
+		}
+	}
+
+	if scope.Gateway != nil {
+		excluded = append(excluded, [2]uint32{IPToUint32(scope.Gateway), IPToUint32(scope.Gateway)})
+	}
+
+	for _, reservedRange := range reserved {
+		addressRange, err := ParseRange(reservedRange)
+		if err != nil {
+			return scope, err
+		}
+
+		first, last := IPToUint32(addressRange.First), IPToUint32(addressRange.Last)
+		if last < IPToUint32(scope.Info.Network) || first > IPToUint32(scope.Info.Broadcast) {
+			return scope, fmt.Errorf("the reserved range %s is outside of the network", reservedRange)
+		}
+
+		excluded = append(excluded, [2]uint32{first, last})
+	}
+
+	for _, server := range dns {
+		serverIP := net.ParseIP(server).To4()
+		if serverIP == nil {
+			return scope, fmt.Errorf("invalid DNS server %s", server)
+		}
+		scope.Dns = append(scope.Dns, []uint8(serverIP))
+	}
+
+	// The pools are the usable addresses minus the excluded ones
+	err = scope.buildPools(excluded, hostMin, hostMax)
+
+	return scope, err
+}
+
+// buildPools builds the pools as the usable range minus the excluded ranges
+func (scope *DhcpScope) buildPools(excluded [][2]uint32, hostMin uint32, hostMax uint32) error {
+	scope.Pools = make([]AddressRange, 0)
+
+	start := uint64(hostMin)
+	for start <= uint64(hostMax) {
+		// Skip the start if it is excluded
+		moved := false
+		for _, exclusion := range excluded {
+			if start >= uint64(exclusion[0]) && start <= uint64(exclusion[1]) {
+				start = uint64(exclusion[1]) + 1
+				moved = true
+			}
+		}
+		if moved {
+			continue
+		}
+
+		// The pool ends right before the nearest exclusion
+		end := uint64(hostMax)
+		for _, exclusion := range excluded {
+			if uint64(exclusion[0]) > start && uint64(exclusion[0])-1 < end {
+				end = uint64(exclusion[0]) - 1
+			}
+		}
+
+		scope.Pools = append(scope.Pools, AddressRange{Uint32ToIP(uint32(start)), Uint32ToIP(uint32(end))})
+		start = end + 1
+	}
+
+	if len(scope.Pools) == 0 {
+		return errors.New("no addresses left for the pool")
+	}
+
+	return scope.Validate()
+}
+
+// Validate checks that the pools contain only usable addresses and never the gateway
+func (scope DhcpScope) Validate() error {
+	network := IPToUint32(scope.Info.Network)
+	broadcast := IPToUint32(scope.Info.Broadcast)
+
+	for _, pool := range scope.Pools {
+		first, last := IPToUint32(pool.First), IPToUint32(pool.Last)
+
+		if first > last {
+			return fmt.Errorf("the pool %s ends before it starts", pool)
+		}
+
+		if first <= network || last >= broadcast {
+			return fmt.Errorf("the pool %s includes the network or broadcast address", pool)
+		}
+
+		if scope.Gateway != nil && IPToUint32(scope.Gateway) >= first && IPToUint32(scope.Gateway) <= last {
+			return fmt.Errorf("the pool %s includes the gateway", pool)
+		}
+	}
+
+	return nil
+}
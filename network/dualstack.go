@@ -0,0 +1,127 @@
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// VlanRequest is a VLAN to be addressed by PlanDualStack
+type VlanRequest struct {
+	ID    uint64
+	Name  string
+	Hosts uint64
+}
+
+// DualStackVlan is a VLAN of a DualStackPlan
+type DualStackVlan struct {
+	VlanRequest
+	IPv4   Block
+	Info   NetworkInfo // Masks, broadcast and hosts range of the IPv4 subnet
+	Usable uint64
+	IPv6   Prefix6
+}
+
+// DualStackPlan contains the result of the PlanDualStack function
+type DualStackPlan struct {
+	IPv4  Block
+	IPv6  Prefix6
+	Vlans []DualStackVlan
+}
+
+// PlanDualStack sizes an IPv4 subnet for every VLAN (VLSM) and pairs it with the /64 that contains the VLAN ID
+func PlanDualStack(ipv4 string, ipv6 string, vlans []VlanRequest) (DualStackPlan, error) {
+	var plan DualStackPlan
+
+	parent, err := ParseBlock(ipv4)
+	if err != nil {
+		return plan, err
+	}
+
+	aggregate, err := ParsePrefix6(ipv6)
+	if err != nil {
+		return plan, err
+	}
+
+	if aggregate.Length > 64 {
+		return plan, fmt.Errorf("the IPv6 aggregate must be a /64 or bigger")
+	}
+
+	// Size every IPv4 subnet
+	prefixes := make([]uint8, 0, len(vlans))
+	seen := make(map[uint64]bool)
+	for _, vlan := range vlans {
+		if seen[vlan.ID] {
+			return plan, fmt.Errorf("the VLAN %d is repeated", vlan.ID)
+		}
+		seen[vlan.ID] = true
+
+		prefix, err := PrefixForHosts(vlan.Hosts, Standard.Reserved())
+		if err != nil {
+			return plan, fmt.Errorf("VLAN %d: %s", vlan.ID, err)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+
+	blocks, err := AllocateBlocks(parent, prefixes)
+	if err != nil {
+		return plan, err
+	}
+
+	plan.IPv4 = parent
+	plan.IPv6 = aggregate
+	plan.Vlans = make([]DualStackVlan, 0, len(vlans))
+	for i, vlan := range vlans {
+		ipv6Subnet, err := aggregate.Subnet(64, VlanSubnetID(vlan.ID))
+		if err != nil {
+			return plan, fmt.Errorf("VLAN %d: %s", vlan.ID, err)
+		}
+
+		info := CalculateNetwork(ByteArrToStr(blocks[i].Network), ByteArrToStr(blocks[i].Netmask.Dotted))
+		plan.Vlans = append(plan.Vlans, DualStackVlan{
+			VlanRequest: vlan,
+			IPv4:        blocks[i],
+			Info:        info,
+			Usable:      uint64(info.HostsQuantity),
+			IPv6:        ipv6Subnet,
+		})
+	}
+
+	return plan, nil
+}
+
+// CSV returns the plan as CSV, one row for every VLAN
+func (plan DualStackPlan) CSV() string {
+	var csv strings.Builder
+
+	csv.WriteString("vlan,name,hosts,ipv4,usable,ipv6\n")
+	for _, vlan := range plan.Vlans {
+		fmt.Fprintf(&csv, "%d,%s,%d,%s,%d,%s\n", vlan.ID, strings.ReplaceAll(vlan.Name, ",", " "), vlan.Hosts, vlan.IPv4, vlan.Usable, vlan.IPv6)
+	}
+
+	return csv.String()
+}
+
+// JSON returns the plan as indented JSON
+func (plan DualStackPlan) JSON() ([]byte, error) {
+	type jsonVlan struct {
+		ID     uint64 `json:"vlan"`
+		Name   string `json:"name,omitempty"`
+		Hosts  uint64 `json:"hosts"`
+		IPv4   string `json:"ipv4"`
+		Usable uint64 `json:"usable"`
+		IPv6   string `json:"ipv6"`
+	}
+	type jsonPlan struct {
+		IPv4  string     `json:"ipv4"`
+		IPv6  string     `json:"ipv6"`
+		Vlans []jsonVlan `json:"vlans"`
+	}
+
+	output := jsonPlan{plan.IPv4.String(), plan.IPv6.String(), make([]jsonVlan, 0, len(plan.Vlans))}
+	for _, vlan := range plan.Vlans {
+		output.Vlans = append(output.Vlans, jsonVlan{vlan.ID, vlan.Name, vlan.Hosts, vlan.IPv4.String(), vlan.Usable, vlan.IPv6.String()})
+	}
+
+	return json.MarshalIndent(output, "", "  ")
+}
@@ -0,0 +1,112 @@
+package network
+
+import (
+	"math/big"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Defanged dots and colons, like 10[.]0[.]0[.]1, 10(dot)0(dot)0(dot)1 or 2001[:]db8[:][:]1
+var (
+	defangedDotPattern   = regexp.MustCompile(`(?i)\s*[\[({]\s*(?:\.|dot)\s*[\])}]\s*`)
+	defangedColonPattern = regexp.MustCompile(`(?i)[\[({](?::|colon)[\])}]`)
+	defangedSlashPattern = regexp.MustCompile(`[\[({]/[\])}]`)
+)
+
+// Candidate addresses and prefixes inside any text, validated by net.ParseIP
+var prefixPattern = regexp.MustCompile(`(?:` + addressPattern.String() + `)(?:/\d{1,3})?`)
+
+// Special purpose IPv6 ranges (RFC 6890) and their scope, the most specific ones first
+var ipv6Scopes = []struct {
+	cidr  string
+	scope string
+}{
+	{"::/128", "unspecified"},
+	{"::1/128", "loopback"},
+	{"::ffff:0:0/96", "IPv4-mapped"},
+	{"64:ff9b::/96", "NAT64"},
+	{"2001::/32", "Teredo"},
+	{"2001:db8::/32", "documentation"},
+	{"2002::/16", "6to4"},
+	{"fc00::/7", "unique local"},
+	{"fe80::/10", "link-local"},
+	{"ff00::/8", "multicast"},
+	{"2000::/3", "global unicast"},
+}
+
+// Refang restores the addresses written in defanged form, like the ones of the threat intelligence reports
+func Refang(text string) string {
+	text = defangedDotPattern.ReplaceAllString(text, ".")
+	text = defangedColonPattern.ReplaceAllString(text, ":")
+	return defangedSlashPattern.ReplaceAllString(text, "/")
+}
+
+// ExtractPrefixes returns every IPv4 and IPv6 address or prefix of a text, defanged ones included, in order of
+// appearance and without duplicates. The addresses are single address prefixes, the host bits of the prefixes are kept
+func ExtractPrefixes(text string) []*net.IPNet {
+	prefixes := make([]*net.IPNet, 0)
+	seen := make(map[string]bool)
+
+	for _, match := range findAddresses(prefixPattern, Refang(text)) {
+		var prefix *net.IPNet
+		if ip, ipNet, err := net.ParseCIDR(match); err == nil {
+			if ip4 := ip.To4(); ip4 != nil && len(ipNet.IP) == net.IPv4len {
+				ip = ip4
+			}
+			prefix = &net.IPNet{IP: ip, Mask: ipNet.Mask}
+		} else if ip := parseAddress(strings.SplitN(match, "/", 2)[0]); ip != nil {
+			prefix = hostNet(ip)
+		} else {
+			continue
+		}
+
+		if !seen[prefix.String()] {
+			seen[prefix.String()] = true
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	return prefixes
+}
+
+// ClassifyIP returns the IP version and the special purpose scope of an address, like "IPv4 private"
+func ClassifyIP(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		return "IPv4 class " + AddressClass(ip4) + ", " + AddressScope(ip4)
+	}
+
+	for _, special := range ipv6Scopes {
+		_, ipNet, _ := net.ParseCIDR(special.cidr)
+		if ipNet.Contains(ip) {
+			return "IPv6 " + special.scope
+		}
+	}
+
+	return "IPv6 reserved"
+}
+
+// DescribePrefix returns the main values of an address or prefix on a single line: network, usable range and size
+func DescribePrefix(prefix *net.IPNet) string {
+	ones, bits := prefix.Mask.Size()
+	if ones == bits {
+		return prefix.IP.String() + ": single address, " + ClassifyIP(prefix.IP)
+	}
+
+	network := &net.IPNet{IP: prefix.IP.Mask(prefix.Mask), Mask: prefix.Mask}
+	if bits == 32 {
+		info := CalculateNetwork(prefix.IP.To4().String(), ByteArrToStr(CidrToMask(uint8(ones)).Dotted))
+		text := network.String() + ": broadcast " + ByteArrToStr(info.Broadcast)
+		if ones <= 30 {
+			text += ", hosts " + ByteArrToStr(info.HostMinAddress) + " - " + ByteArrToStr(info.HostMaxAddress) + " (" + strconv.FormatUint(uint64(1)<<uint(32-ones)-2, 10) + ")"
+		}
+		return text
+	}
+
+	// The last address has all the host bits set
+	last := new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+	last.Sub(last, big.NewInt(1)).Or(last, IPToInt(network.IP))
+
+	return network.String() + ": last " + IntToIP(last, net.IPv6len).String() + ", 2^" + strconv.Itoa(bits-ones) + " addresses"
+}
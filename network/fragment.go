@@ -0,0 +1,61 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Largest IPv4 datagram, the total length is a 16 bits field
+const maxDatagram = 65535
+
+// Fragment is an IPv4 fragment of a datagram
+type Fragment struct {
+	TotalLength   int  // Header and data
+	Offset        int  // Offset of the data in the original payload, in bytes
+	DataLength    int  // Data carried by the fragment
+	MoreFragments bool // MF flag, set on every fragment but the last
+}
+
+// OffsetUnits returns the fragment offset field, which counts 8 bytes units
+func (fragment Fragment) OffsetUnits() int {
+	return fragment.Offset / 8
+}
+
+// FragmentData returns the data carried by every fragment but the last: what fits in the MTU after the header,
+// rounded down to a multiple of 8 because the offsets are in 8 bytes units
+func FragmentData(mtu int, header int) int {
+	return (mtu - header) / 8 * 8
+}
+
+// FragmentDatagram splits an IPv4 payload in the fragments of an MTU, every fragment having a header of the given
+// length (20 to 60 bytes). A payload that fits in the MTU gives a single fragment
+func FragmentDatagram(payload int, mtu int, header int) ([]Fragment, error) {
+	if header < 20 || header > 60 || header%4 != 0 {
+		return nil, errors.New("the IPv4 header is 20 to 60 bytes, in multiples of 4")
+	}
+	if payload < 0 || payload+header > maxDatagram {
+		return nil, fmt.Errorf("the payload must be between 0 and %d bytes with a %d bytes header", maxDatagram-header, header)
+	}
+	if mtu < 68 || mtu > maxDatagram {
+		return nil, fmt.Errorf("the MTU must be between 68 (RFC 791) and %d bytes", maxDatagram)
+	}
+	if FragmentData(mtu, header) == 0 {
+		return nil, errors.New("the header leaves no room for the data")
+	}
+
+	if payload+header <= mtu {
+		return []Fragment{{payload + header, 0, payload, false}}, nil
+	}
+
+	step := FragmentData(mtu, header)
+	fragments := make([]Fragment, 0, payload/step+1)
+	for offset := 0; offset < payload; offset += step {
+		data := step
+		if payload-offset <= step {
+			data = payload - offset
+		}
+		fragments = append(fragments, Fragment{data + header, offset, data, offset+data < payload})
+	}
+
+	return fragments, nil
+}
@@ -0,0 +1,197 @@
+package network
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+)
+
+// First and last addresses of a block, as numeric values
+func (block Block) bounds() (uint64, uint64) {
+	first := uint64(IPToUint32(block.Network))
+	return first, first + block.Size() - 1
+}
+
+// Contains tells if other is entirely inside the block
+func (block Block) Contains(other Block) bool {
+	first, last := block.bounds()
+	otherFirst, otherLast := other.bounds()
+
+	return otherFirst >= first && otherLast <= last
+}
+
+// sortBlocks returns a copy of the blocks sorted by first address
+func sortBlocks(blocks []Block) []Block {
+	sorted := make([]Block, len(blocks))
+	copy(sorted, blocks)
+	sort.Slice(sorted, func(a, b int) bool {
+		return IPToUint32(sorted[a].Network) < IPToUint32(sorted[b].Network)
+	})
+
+	return sorted
+}
+
+// NextFreeBlock returns the first aligned block with the given prefix inside parent that doesn't overlap any used block
+func NextFreeBlock(parent Block, used []Block, prefix uint8) (Block, error) {
+	if prefix < parent.Netmask.Decimal || prefix > 32 {
+		return Block{}, fmt.Errorf("a /%d doesn't fit in %s", prefix, parent)
+	}
+
+	size := uint64(1) << (32 - uint64(prefix))
+	cursor, parentLast := parent.bounds()
+
+	// Jump after every used block in the way, keeping the cursor aligned
+	for _, block := range sortBlocks(used) {
+		first, last := block.bounds()
+		if last < cursor {
+			continue
+		}
+
+		if cursor+size-1 < first {
+			break
+		}
+
+		cursor = (last + 1 + size - 1) &^ (size - 1)
+	}
+
+	if cursor+size-1 > parentLast {
+		return Block{}, fmt.Errorf("no free /%d left in %s", prefix, parent)
+	}
+
+	return Block{Uint32ToIP(uint32(cursor)), CidrToMask(prefix)}, nil
+}
+
+// FreeRanges returns the usable addresses of a block that aren't in used, as ranges, with their quantity.
+// The network and broadcast addresses are never free, except for /31 and /32 blocks
+func FreeRanges(block Block, used [][]uint8) ([]AddressRange, uint64) {
+	first, last := block.bounds()
+	if block.Netmask.Decimal <= 30 {
+		first++
+		last--
+	}
+
+	// Sort the used addresses inside the block
+	usedNums := make([]uint64, 0, len(used))
+	for _, address := range used {
+		num := uint64(IPToUint32(address))
+		if num >= first && num <= last {
+			usedNums = append(usedNums, num)
+		}
+	}
+	sort.Slice(usedNums, func(a, b int) bool {
+		return usedNums[a] < usedNums[b]
+	})
+
+	ranges := make([]AddressRange, 0)
+	var quantity uint64
+	cursor := first
+	for _, num := range append(usedNums, last+1) {
+		if num > cursor {
+			ranges = append(ranges, AddressRange{Uint32ToIP(uint32(cursor)), Uint32ToIP(uint32(num - 1))})
+			quantity += num - cursor
+		}
+		if num+1 > cursor {
+			cursor = num + 1
+		}
+	}
+
+	return ranges, quantity
+}
+
+// FreeSpace contains the result of the FindFree function
+type FreeSpace struct {
+	Parent  Block
+	Ranges  []AddressRange // Contiguous free ranges
+	Blocks  []Block        // Free ranges split in aligned blocks
+	Free    uint64         // Quantity of free addresses
+	Largest Block          // Biggest free aligned block, empty if there is no free space
+}
+
+// RangeBlocks splits an inclusive range of numeric addresses in the fewest aligned blocks
+func RangeBlocks(first uint64, last uint64) []Block {
+	blocks := make([]Block, 0)
+
+	for first <= last {
+		// Biggest block aligned to first that doesn't go after last
+		hostBits := uint64(bits.TrailingZeros64(first))
+		if first == 0 || hostBits > 32 {
+			hostBits = 32
+		}
+		for hostBits > 0 && first+(uint64(1)<<hostBits)-1 > last {
+			hostBits--
+		}
+
+		blocks = append(blocks, Block{Uint32ToIP(uint32(first)), CidrToMask(uint8(32 - hostBits))})
+		first += uint64(1) << hostBits
+	}
+
+	return blocks
+}
+
+// FindFree returns the space of parent not covered by the used blocks, which can overlap each other
+func FindFree(parent Block, used []Block) FreeSpace {
+	space := FreeSpace{Parent: parent, Ranges: make([]AddressRange, 0), Blocks: make([]Block, 0)}
+	cursor, parentLast := parent.bounds()
+
+	// Add the free range between the cursor and first, if any
+	addRange := func(first uint64) {
+		if first > cursor {
+			space.Ranges = append(space.Ranges, AddressRange{Uint32ToIP(uint32(cursor)), Uint32ToIP(uint32(first - 1))})
+			space.Blocks = append(space.Blocks, RangeBlocks(cursor, first-1)...)
+			space.Free += first - cursor
+		}
+	}
+
+	for _, block := range sortBlocks(used) {
+		first, last := block.bounds()
+		if last < cursor || first > parentLast {
+			continue
+		}
+
+		addRange(first)
+		if last+1 > cursor {
+			cursor = last + 1
+		}
+	}
+	addRange(parentLast + 1)
+
+	for _, block := range space.Blocks {
+		if space.Largest.Network == nil || block.Netmask.Decimal < space.Largest.Netmask.Decimal {
+			space.Largest = block
+		}
+	}
+
+	return space
+}
+
+// BlocksOf returns up to limit free aligned blocks with the given prefix, with the total quantity of them
+func (space FreeSpace) BlocksOf(prefix uint8, limit int) ([]Block, uint64) {
+	found := make([]Block, 0)
+	var quantity uint64
+
+	for _, block := range space.Blocks {
+		if block.Netmask.Decimal > prefix {
+			continue
+		}
+
+		subBlocks := uint64(1) << (uint64(prefix) - uint64(block.Netmask.Decimal))
+		quantity += subBlocks
+
+		first := uint64(IPToUint32(block.Network))
+		size := uint64(1) << (32 - uint64(prefix))
+		for i := uint64(0); i < subBlocks && len(found) < limit; i++ {
+			found = append(found, Block{Uint32ToIP(uint32(first + i*size)), CidrToMask(prefix)})
+		}
+	}
+
+	return found, quantity
+}
+
+// Fragmentation returns the percentage of free space outside the largest free block
+func (space FreeSpace) Fragmentation() float64 {
+	if space.Free == 0 {
+		return 0
+	}
+
+	return float64(space.Free-space.Largest.Size()) * 100 / float64(space.Free)
+}
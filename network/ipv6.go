@@ -0,0 +1,195 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Prefix6 is an IPv6 network
+type Prefix6 struct {
+	Network net.IP
+	Length  uint8
+}
+
+// V6Vlan is a /64 of a V6Site
+type V6Vlan struct {
+	ID     uint64
+	Prefix Prefix6
+}
+
+// V6Site is a site of a V6Plan
+type V6Site struct {
+	Index  uint64
+	Prefix Prefix6
+	Vlans  []V6Vlan
+}
+
+// V6Plan contains the result of the PlanIPv6 function
+type V6Plan struct {
+	Aggregate  Prefix6
+	SiteLength uint8
+	SiteBits   uint8 // Bits used for the site ID, 2^SiteBits sites fit in the aggregate
+	VlanBits   uint8 // Bits used for the VLAN subnet ID, 2^VlanBits VLANs fit in every site
+	VlanIDs    bool  // True if the subnet ID is made of the VLAN ID digits
+	Sites      []V6Site
+}
+
+// ParsePrefix6 parses an IPv6 "address/prefix" string, clearing the host bits
+func ParsePrefix6(str string) (Prefix6, error) {
+	ip, ipNet, err := net.ParseCIDR(str)
+	if err != nil {
+		return Prefix6{}, err
+	}
+
+	if ip.To4() != nil {
+		return Prefix6{}, errors.New("this isn't an IPv6 network")
+	}
+
+	ones, _ := ipNet.Mask.Size()
+
+	return Prefix6{ipNet.IP.To16(), uint8(ones)}, nil
+}
+
+// String returns the prefix in "network/length" notation
+func (prefix Prefix6) String() string {
+	return prefix.Network.String() + "/" + fmt.Sprint(prefix.Length)
+}
+
+// Subnet returns the subnet with the given length and index inside the prefix
+func (prefix Prefix6) Subnet(length uint8, index uint64) (Prefix6, error) {
+	if length < prefix.Length || length > 128 {
+		return Prefix6{}, fmt.Errorf("a /%d isn't a subnet of a /%d", length, prefix.Length)
+	}
+
+	subnetBits := length - prefix.Length
+	if subnetBits < 64 && index >= 1<<subnetBits {
+		return Prefix6{}, fmt.Errorf("only %d /%d fit in a /%d", uint64(1)<<subnetBits, length, prefix.Length)
+	}
+
+	// Write the index bits right before the new prefix length, from the least significant one
+	network := make(net.IP, net.IPv6len)
+	copy(network, prefix.Network)
+	for bit := uint8(0); bit < subnetBits && bit < 64; bit++ {
+		position := length - 1 - bit
+		if index&(1<<bit) != 0 {
+			network[position/8] |= 0x80 >> (position % 8)
+		}
+	}
+
+	return Prefix6{network, length}, nil
+}
+
+// BitsFor returns the bits needed to give a different ID to quantity items
+func BitsFor(quantity uint64) uint8 {
+	if quantity <= 1 {
+		return 0
+	}
+
+	return uint8(bits.Len64(quantity - 1))
+}
+
+// NibbleCeil rounds a prefix length up to the next nibble boundary
+func NibbleCeil(length uint8) uint8 {
+	return (length + 3) &^ 3
+}
+
+// PowerOfTwo formats 2^exponent, using the exponent notation when the number is too big to read
+func PowerOfTwo(exponent uint8) string {
+	if exponent < 40 {
+		return fmt.Sprint(uint64(1) << exponent)
+	}
+
+	return fmt.Sprintf("2^%d", exponent)
+}
+
+// VlanSubnetID returns the IPv6 subnet ID of a VLAN, made of the same digits of the VLAN ID so 120 becomes :120:
+func VlanSubnetID(id uint64) uint64 {
+	subnetID, _ := strconv.ParseUint(strconv.FormatUint(id, 10), 16, 64)
+	return subnetID
+}
+
+// PlanIPv6 carves an aggregate into nibble aligned sites, each one with a /64 for every VLAN.
+// When vlanIDs is true the subnet ID repeats the VLAN ID digits (see VlanSubnetID), otherwise the VLANs are numbered in order
+func PlanIPv6(aggregate string, sites uint64, vlans []uint64, vlanIDs bool) (V6Plan, error) {
+	var plan V6Plan
+
+	prefix, err := ParsePrefix6(aggregate)
+	if err != nil {
+		return plan, err
+	}
+
+	if prefix.Length > 64 {
+		return plan, errors.New("the aggregate must be a /64 or bigger")
+	}
+
+	if sites == 0 {
+		return plan, errors.New("at least a site is needed")
+	}
+
+	// The VLAN bits must be able to contain every VLAN ID (or every VLAN, if they are numbered)
+	vlanBitsNeeded := BitsFor(uint64(len(vlans)))
+	if vlanIDs {
+		var maxID uint64
+		for _, id := range vlans {
+			if VlanSubnetID(id) > maxID {
+				maxID = VlanSubnetID(id)
+			}
+		}
+		vlanBitsNeeded = BitsFor(maxID + 1)
+	}
+	vlanBitsNeeded = NibbleCeil(vlanBitsNeeded)
+
+	plan.Aggregate = prefix
+	plan.VlanIDs = vlanIDs
+	plan.SiteLength = NibbleCeil(prefix.Length + BitsFor(sites))
+	if plan.SiteLength > 64 || 64-plan.SiteLength < vlanBitsNeeded {
+		return plan, fmt.Errorf("%d sites with %d VLAN bits don't fit in a /%d", sites, vlanBitsNeeded, prefix.Length)
+	}
+
+	plan.SiteBits = plan.SiteLength - prefix.Length
+	plan.VlanBits = 64 - plan.SiteLength
+
+	plan.Sites = make([]V6Site, 0, sites)
+	for siteIndex := uint64(0); siteIndex < sites; siteIndex++ {
+		sitePrefix, err := prefix.Subnet(plan.SiteLength, siteIndex)
+		if err != nil {
+			return plan, err
+		}
+
+		site := V6Site{siteIndex, sitePrefix, make([]V6Vlan, 0, len(vlans))}
+		for i, id := range vlans {
+			subnetID := uint64(i)
+			if vlanIDs {
+				subnetID = VlanSubnetID(id)
+			}
+
+			vlanPrefix, err := sitePrefix.Subnet(64, subnetID)
+			if err != nil {
+				return plan, err
+			}
+			site.Vlans = append(site.Vlans, V6Vlan{id, vlanPrefix})
+		}
+
+		plan.Sites = append(plan.Sites, site)
+	}
+
+	return plan, nil
+}
+
+// CSV returns the plan as CSV, one row for every VLAN of every site
+func (plan V6Plan) CSV() string {
+	var csv strings.Builder
+
+	csv.WriteString("site,site_prefix,vlan,prefix\n")
+	for _, site := range plan.Sites {
+		for _, vlan := range site.Vlans {
+			fmt.Fprintf(&csv, "%d,%s,%d,%s\n", site.Index, site.Prefix, vlan.ID, vlan.Prefix)
+		}
+	}
+
+	return csv.String()
+}
@@ -0,0 +1,140 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Supported Kubernetes network plugins
+const (
+	Kubenet = "kubenet"
+	Calico  = "calico"
+	AwsVpc  = "awsvpc"
+	Cilium  = "cilium"
+)
+
+// CalicoBlockPrefix is the size of the IPAM blocks Calico assigns to the nodes
+const CalicoBlockPrefix = 26
+
+// KubernetesPlan contains the result of the PlanKubernetes function
+type KubernetesPlan struct {
+	Cni           string
+	Nodes         Block
+	Pods          Block // Empty if the pods take their addresses from the nodes network
+	Services      Block
+	PodPrefix     uint8 // Prefix of the pods block assigned to every node
+	BlocksPerNode int   // Quantity of pods blocks assigned to every node
+	Conflicts     []string
+}
+
+// FindCni returns the name of a supported network plugin, accepting some aliases
+func FindCni(name string) (string, bool) {
+	switch strings.ToLower(name) {
+	case "kubenet":
+		return Kubenet, true
+	case "calico":
+		return Calico, true
+	case "awsvpc", "aws-vpc-cni", "vpc-cni", "aws":
+		return AwsVpc, true
+	case "cilium", "cluster-pool":
+		return Cilium, true
+	}
+
+	return "", false
+}
+
+// PlanKubernetes computes non-overlapping nodes, pods and services networks inside base, flagging the ones overlapping the supernet (empty to skip)
+func PlanKubernetes(nodes, maxPods, services uint64, cni string, base string, supernet string) (KubernetesPlan, error) {
+	var plan KubernetesPlan
+	plan.Cni = cni
+
+	if nodes == 0 || maxPods == 0 || services == 0 {
+		return plan, errors.New("nodes, pods per node and services must be greater than 0")
+	}
+
+	parent, err := ParseBlock(base)
+	if err != nil {
+		return plan, err
+	}
+
+	// Size the services network, the API server doesn't allow ranges bigger than a /12
+	servicesPrefix, err := PrefixForHosts(services, Standard.Reserved())
+	if err != nil {
+		return plan, err
+	}
+	if servicesPrefix < 12 {
+		return plan, errors.New("the services network can't be bigger than a /12")
+	}
+
+	// Size the nodes and pods networks depending on how the plugin assigns the pods addresses
+	var nodesPrefix, podsPrefix uint8
+	switch cni {
+	case AwsVpc:
+		// Pods take their addresses from the node subnet, every node uses an address for itself too
+		nodesPrefix, err = PrefixForHosts(nodes*(maxPods+1), Providers["aws"].Reserved())
+		if err != nil {
+			return plan, err
+		}
+	case Calico:
+		// Every node gets as many /26 blocks as needed for its pods
+		plan.PodPrefix = CalicoBlockPrefix
+		blockSize := uint64(1) << (32 - CalicoBlockPrefix)
+		plan.BlocksPerNode = int((maxPods + blockSize - 1) / blockSize)
+		podsPrefix, err = PrefixForHosts(nodes*uint64(plan.BlocksPerNode)*blockSize, 0)
+	case Kubenet, Cilium:
+		// Every node gets a single block with twice the addresses of its pods, so they can be replaced without reusing addresses
+		plan.BlocksPerNode = 1
+		plan.PodPrefix, err = PrefixForHosts(maxPods*2, 0)
+		if err != nil {
+			return plan, err
+		}
+		podsPrefix, err = PrefixForHosts(nodes<<(32-uint64(plan.PodPrefix)), 0)
+	default:
+		return plan, fmt.Errorf("unknown network plugin %s", cni)
+	}
+	if err != nil {
+		return plan, err
+	}
+
+	if cni != AwsVpc {
+		nodesPrefix, err = PrefixForHosts(nodes, Standard.Reserved())
+		if err != nil {
+			return plan, err
+		}
+	}
+
+	// Allocate the networks from base
+	prefixes := []uint8{nodesPrefix, servicesPrefix}
+	if cni != AwsVpc {
+		prefixes = append(prefixes, podsPrefix)
+	}
+
+	blocks, err := AllocateBlocks(parent, prefixes)
+	if err != nil {
+		return plan, err
+	}
+
+	plan.Nodes = blocks[0]
+	plan.Services = blocks[1]
+	if cni != AwsVpc {
+		plan.Pods = blocks[2]
+	}
+
+	// Flag the networks that overlap the supernet
+	if supernet != "" {
+		corporate, err := ParseBlock(supernet)
+		if err != nil {
+			return plan, err
+		}
+
+		names := []string{"Nodes", "Services", "Pods"}
+		for i, block := range blocks {
+			if block.Overlaps(corporate) {
+				plan.Conflicts = append(plan.Conflicts, fmt.Sprintf("%s network %s overlaps %s", names[i], block, corporate))
+			}
+		}
+	}
+
+	return plan, nil
+}
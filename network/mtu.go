@@ -0,0 +1,215 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Encapsulation is a header, or a stack of headers like a tunnel, added to every packet
+type Encapsulation struct {
+	Name    string
+	header  int // Bytes before the payload
+	clear   int // Bytes of the packet left before the encapsulation, like the IP header in ESP transport mode
+	trailer int // Bytes after the payload, included in the padding
+	block   int // The payload and its trailer are padded to a multiple of block, 0 if they aren't
+	tail    int // Bytes after the padding, like the integrity check value of ESP
+}
+
+// espCipher contains the sizes of an ESP transform
+type espCipher struct {
+	iv    int // Initialization vector
+	block int // Block size of the padding, at least 4 (RFC 4303)
+	icv   int // Integrity check value
+}
+
+// Transforms of IPsec ESP by name, the first one is the default
+var espCiphers = map[string]espCipher{
+	"aes-gcm":           {8, 4, 16},
+	"aes-cbc-sha1":      {16, 16, 12},
+	"aes-cbc-sha256":    {16, 16, 16},
+	"aes-cbc-sha384":    {16, 16, 24},
+	"aes-cbc-sha512":    {16, 16, 32},
+	"3des-sha1":         {8, 8, 12},
+	"chacha20-poly1305": {8, 4, 16},
+}
+
+// Sizes of the headers used by the tunnels
+const (
+	ipv4Header     = 20
+	ipv6Header     = 40
+	udpHeader      = 8
+	tcpHeader      = 20
+	ethernetHeader = 14
+)
+
+// EncapsulationNames returns the names accepted by ParseEncapsulation, with their options
+func EncapsulationNames() []string {
+	ciphers := make([]string, 0, len(espCiphers))
+	for cipher := range espCiphers {
+		ciphers = append(ciphers, cipher)
+	}
+	sort.Strings(ciphers)
+
+	return []string{
+		"dot1q", "qinq", "mpls[:labels]", "pppoe", "gre[:key]", "ipip", "vxlan", "geneve[:option bytes]", "wireguard",
+		"esp[:tunnel|transport][:natt][:" + strings.Join(ciphers, "|") + "]",
+	}
+}
+
+// ParseEncapsulation reads an encapsulation like "vxlan", "mpls:2" or "esp:transport:aes-cbc-sha256:ipv6". The tunnels
+// and ESP add an outer IPv4 header, or an IPv6 one with the ipv6 option
+func ParseEncapsulation(token string) (Encapsulation, error) {
+	parts := strings.Split(strings.ToLower(token), ":")
+	name, options := parts[0], parts[1:]
+
+	// Options shared by the tunnels
+	outer, outerName := ipv4Header, "IPv4"
+	number := -1
+	remaining := make([]string, 0, len(options))
+	for _, option := range options {
+		switch option {
+		case "ipv4":
+		case "ipv6":
+			outer, outerName = ipv6Header, "IPv6"
+		default:
+			if value, err := strconv.Atoi(option); err == nil && value >= 0 {
+				number = value
+			} else {
+				remaining = append(remaining, option)
+			}
+		}
+	}
+
+	var encapsulation Encapsulation
+	switch name {
+	case "dot1q", "vlan", "802.1q":
+		encapsulation = Encapsulation{Name: "802.1Q VLAN tag", header: 4}
+	case "qinq", "802.1ad":
+		encapsulation = Encapsulation{Name: "QinQ double VLAN tag", header: 8}
+	case "mpls":
+		if number < 0 {
+			number = 1
+		}
+		if number == 0 || number > 16 {
+			return Encapsulation{}, errors.New("an MPLS stack has 1 to 16 labels")
+		}
+		encapsulation = Encapsulation{Name: fmt.Sprintf("MPLS, %d labels", number), header: 4 * number}
+	case "pppoe":
+		encapsulation = Encapsulation{Name: "PPPoE", header: 8}
+	case "gre":
+		encapsulation = Encapsulation{Name: "GRE over " + outerName, header: outer + 4}
+		if len(remaining) > 0 && remaining[0] == "key" {
+			encapsulation = Encapsulation{Name: "GRE with key over " + outerName, header: outer + 8}
+			remaining = remaining[1:]
+		}
+	case "ipip", "ipinip":
+		encapsulation = Encapsulation{Name: "IP in " + outerName, header: outer}
+	case "vxlan":
+		encapsulation = Encapsulation{Name: "VXLAN over " + outerName, header: outer + udpHeader + 8 + ethernetHeader}
+	case "geneve":
+		if number < 0 {
+			number = 0
+		}
+		if number%4 != 0 || number > 252 {
+			return Encapsulation{}, errors.New("the Geneve options are 0 to 252 bytes, in multiples of 4")
+		}
+		encapsulation = Encapsulation{Name: fmt.Sprintf("Geneve over %s, %d bytes of options", outerName, number), header: outer + udpHeader + 8 + number + ethernetHeader}
+	case "wireguard", "wg":
+		// Header of the transport data message and Poly1305 tag
+		encapsulation = Encapsulation{Name: "WireGuard over " + outerName, header: outer + udpHeader + 16, tail: 16}
+	case "esp", "ipsec":
+		mode, cipherName, natt := "tunnel", "aes-gcm", false
+		for _, option := range remaining {
+			if _, found := espCiphers[option]; found {
+				cipherName = option
+				continue
+			}
+
+			switch option {
+			case "tunnel", "transport":
+				mode = option
+			case "natt", "nat-t":
+				natt = true
+			default:
+				return Encapsulation{}, fmt.Errorf("unknown ESP option %s", option)
+			}
+		}
+		remaining = nil
+
+		// SPI and sequence number, then the IV. The transport mode keeps the original IP header before ESP
+		cipher := espCiphers[cipherName]
+		encapsulation = Encapsulation{header: 8 + cipher.iv, trailer: 2, block: cipher.block, tail: cipher.icv}
+		if mode == "tunnel" {
+			encapsulation.header += outer
+		} else {
+			encapsulation.clear = outer
+		}
+		if natt {
+			encapsulation.header += udpHeader
+		}
+		encapsulation.Name = "IPsec ESP " + mode + " " + cipherName + " over " + outerName
+		if natt {
+			encapsulation.Name += " with NAT-T"
+		}
+	default:
+		return Encapsulation{}, fmt.Errorf("unknown encapsulation %s", name)
+	}
+
+	if len(remaining) > 0 {
+		return Encapsulation{}, fmt.Errorf("unknown option %s of %s", remaining[0], name)
+	}
+
+	return encapsulation, nil
+}
+
+// Outer returns the size of a packet after the encapsulation
+func (encapsulation Encapsulation) Outer(size int) int {
+	clear := encapsulation.clear
+	if clear > size {
+		clear = size
+	}
+
+	payload := size - clear + encapsulation.trailer
+	if encapsulation.block > 0 {
+		payload = (payload + encapsulation.block - 1) / encapsulation.block * encapsulation.block
+	}
+
+	return clear + encapsulation.header + payload + encapsulation.tail
+}
+
+// WireSize returns the size of a packet after a stack of encapsulations, written from the outermost
+func WireSize(stack []Encapsulation, size int) int {
+	for i := len(stack) - 1; i >= 0; i-- {
+		size = stack[i].Outer(size)
+	}
+
+	return size
+}
+
+// InnerMTU returns the biggest packet that fits in base MTU after a stack of encapsulations, -1 if none fits
+func InnerMTU(base int, stack []Encapsulation) int {
+	// The encapsulations never shrink the packets, so the sizes are searched with a bisection
+	low, high := -1, base
+	for low < high {
+		middle := (low + high + 1) / 2
+		if WireSize(stack, middle) <= base {
+			low = middle
+		} else {
+			high = middle - 1
+		}
+	}
+
+	return low
+}
+
+// TCPMSS returns the TCP maximum segment size of an MTU, with an IPv4 or IPv6 header and no options
+func TCPMSS(mtu int, ipv6 bool) int {
+	if ipv6 {
+		return mtu - ipv6Header - tcpHeader
+	}
+
+	return mtu - ipv4Header - tcpHeader
+}
@@ -56,7 +56,7 @@ func DottedToMask(dotted []byte) Mask {
 	var cidr uint8 = 0
 
 	for i := 0; i < len(dotted); i++ {
-		cidr += uint8(bits.Len8(dotted[i]))
+		cidr += uint8(bits.OnesCount8(dotted[i]))
 	}
 
 	return Mask{cidr, dotted}
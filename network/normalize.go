@@ -0,0 +1,213 @@
+package network
+
+import (
+	"bytes"
+	"math/big"
+	"net"
+	"sort"
+	"strings"
+)
+
+// NormalizedList contains the result of the NormalizeList function
+type NormalizedList struct {
+	Prefixes   []*net.IPNet // Sorted unique prefixes, masked to their network
+	Duplicates int          // Quantity of entries removed because repeated
+	Masked     int          // Quantity of prefixes that had host bits set
+	Invalid    []string     // Tokens that aren't addresses, prefixes or ranges
+}
+
+// CompareIP compares two addresses numerically, the IPv4 ones come before the IPv6 ones.
+// The result is 0 if a == b, -1 if a < b, and +1 if a > b
+func CompareIP(a net.IP, b net.IP) int {
+	a4, b4 := a.To4(), b.To4()
+	switch {
+	case a4 != nil && b4 != nil:
+		return bytes.Compare(a4, b4)
+	case a4 != nil:
+		return -1
+	case b4 != nil:
+		return 1
+	}
+
+	return bytes.Compare(a.To16(), b.To16())
+}
+
+// ComparePrefix compares two prefixes by network address and then by length, the shorter first
+func ComparePrefix(a *net.IPNet, b *net.IPNet) int {
+	if result := CompareIP(a.IP, b.IP); result != 0 {
+		return result
+	}
+
+	aLength, _ := a.Mask.Size()
+	bLength, _ := b.Mask.Size()
+	switch {
+	case aLength < bLength:
+		return -1
+	case aLength > bLength:
+		return 1
+	}
+
+	return 0
+}
+
+// SortPrefixes sorts prefixes numerically with ComparePrefix
+func SortPrefixes(prefixes []*net.IPNet) {
+	sort.SliceStable(prefixes, func(a, b int) bool {
+		return ComparePrefix(prefixes[a], prefixes[b]) < 0
+	})
+}
+
+// PrefixString returns a prefix in CIDR notation, or as a plain address if it contains a single address
+func PrefixString(prefix *net.IPNet) string {
+	if ones, bits := prefix.Mask.Size(); ones == bits {
+		return prefix.IP.String()
+	}
+
+	return prefix.String()
+}
+
+// dottedMask returns the length of a contiguous dotted netmask starting with 255, or -1 if str isn't one
+func dottedMask(str string) int {
+	ip := net.ParseIP(str).To4()
+	if ip == nil || ip[0] != 255 {
+		return -1
+	}
+
+	ones, bits := net.IPMask(ip).Size()
+	if bits == 0 {
+		return -1
+	}
+
+	return ones
+}
+
+// parseListToken reads the prefixes of a token: an address, a prefix (with length or dotted netmask) or a range
+// of addresses "first-last". The second result tells if the host bits of the prefix were set
+func parseListToken(token string) ([]*net.IPNet, bool) {
+	if ip := net.ParseIP(token); ip != nil {
+		return []*net.IPNet{hostNet(ip)}, false
+	}
+
+	if ip, ipNet, err := net.ParseCIDR(token); err == nil {
+		return []*net.IPNet{ipNet}, !ip.Equal(ipNet.IP)
+	}
+
+	// Prefixes with dotted netmask, like 10.0.0.0/255.0.0.0
+	if parts := strings.Split(token, "/"); len(parts) == 2 {
+		ip := net.ParseIP(parts[0]).To4()
+		if length := dottedMask(parts[1]); ip != nil && length >= 0 {
+			mask := net.CIDRMask(length, 32)
+			return []*net.IPNet{{IP: ip.Mask(mask), Mask: mask}}, !ip.Equal(ip.Mask(mask))
+		}
+	}
+
+	// Ranges, like 10.0.0.1-10.0.0.20
+	if parts := strings.Split(token, "-"); len(parts) == 2 {
+		first, last := net.ParseIP(parts[0]), net.ParseIP(parts[1])
+		if first != nil && last != nil && (first.To4() == nil) == (last.To4() == nil) && CompareIP(first, last) <= 0 {
+			return RangeToIPNets(first, last), false
+		}
+	}
+
+	return nil, false
+}
+
+// NormalizeList reads the addresses, prefixes and ranges of a messy list, with any separator and with comments
+// (after #, // or !), and returns them masked to their network, sorted and without duplicates
+func NormalizeList(text string) NormalizedList {
+	list := NormalizedList{Prefixes: make([]*net.IPNet, 0), Invalid: make([]string, 0)}
+	tokens := make([]string, 0)
+
+	for _, line := range strings.Split(text, "\n") {
+		for _, comment := range []string{"#", "//", "!"} {
+			if index := strings.Index(line, comment); index >= 0 {
+				line = line[:index]
+			}
+		}
+
+		for _, token := range strings.FieldsFunc(line, func(r rune) bool {
+			return r == ' ' || r == '\t' || r == '\r' || r == ',' || r == ';' || r == '|'
+		}) {
+			token = strings.TrimRight(strings.Trim(token, "()[]{}<>\"'"), ".")
+			if token != "" {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < len(tokens); i++ {
+		prefixes, masked := parseListToken(tokens[i])
+
+		// An address followed by a dotted netmask, like "10.0.0.0 255.0.0.0"
+		if ip := net.ParseIP(tokens[i]).To4(); ip != nil && i+1 < len(tokens) {
+			if length := dottedMask(tokens[i+1]); length >= 0 {
+				mask := net.CIDRMask(length, 32)
+				prefixes, masked = []*net.IPNet{{IP: ip.Mask(mask), Mask: mask}}, !ip.Equal(ip.Mask(mask))
+				i++
+			}
+		}
+
+		if prefixes == nil {
+			list.Invalid = append(list.Invalid, tokens[i])
+			continue
+		}
+		if masked {
+			list.Masked++
+		}
+
+		for _, prefix := range prefixes {
+			if seen[prefix.String()] {
+				list.Duplicates++
+				continue
+			}
+			seen[prefix.String()] = true
+			list.Prefixes = append(list.Prefixes, prefix)
+		}
+	}
+
+	SortPrefixes(list.Prefixes)
+	return list
+}
+
+// CollapsePrefixes merges overlapping and adjacent prefixes in the fewest prefixes covering the same addresses
+func CollapsePrefixes(prefixes []*net.IPNet) []*net.IPNet {
+	sorted := make([]*net.IPNet, len(prefixes))
+	copy(sorted, prefixes)
+	SortPrefixes(sorted)
+
+	collapsed := make([]*net.IPNet, 0)
+	one := big.NewInt(1)
+	var first, last *big.Int
+	var family int
+
+	// Split the current merged range, if any, in prefixes
+	flush := func() {
+		if first != nil {
+			collapsed = append(collapsed, RangeToIPNets(IntToIP(first, family), IntToIP(last, family))...)
+		}
+	}
+
+	for _, prefix := range sorted {
+		// The family is the one of the mask, because IPv4-mapped IPv6 prefixes have an IPv4 address
+		ones, bits := prefix.Mask.Size()
+		length := bits / 8
+		start := new(big.Int).SetBytes(prefix.IP.To16()[net.IPv6len-length:])
+		end := new(big.Int).Lsh(one, uint(bits-ones))
+		end.Add(end, start).Sub(end, one)
+
+		// Sorted ranges overlap or are adjacent if the new one starts at most one after the end of the current one
+		if first != nil && length == family && start.Cmp(new(big.Int).Add(last, one)) <= 0 {
+			if end.Cmp(last) > 0 {
+				last = end
+			}
+			continue
+		}
+
+		flush()
+		first, last, family = start, end, length
+	}
+	flush()
+
+	return collapsed
+}
@@ -0,0 +1,207 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"net"
+	"sort"
+)
+
+// HostsPlan contains the result of the PlanHosts function
+type HostsPlan struct {
+	Hosts      uint64
+	Provider   Provider
+	Netmask    Mask
+	Wildcard   []uint8
+	BlockSize  uint64
+	Usable     uint64
+	Within     []uint8 // Network of the constraint, nil if there is no constraint
+	WithinMask Mask
+	FirstBlock []uint8 // First aligned block inside the constraint, nil if there is no constraint
+	Blocks     uint64  // Quantity of blocks that fit in the constraint
+}
+
+// IPToUint32 converts a 4 bytes address to its numeric value
+func IPToUint32(ip []byte) uint32 {
+	return uint32(ip[0])<<24 | uint32(ip[1])<<16 | uint32(ip[2])<<8 | uint32(ip[3])
+}
+
+// Uint32ToIP converts a numeric value to a 4 bytes address
+func Uint32ToIP(n uint32) []byte {
+	return []byte{byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}
+}
+
+// ParseCidr parses an IPv4 "address/prefix" string and returns the address with its netmask
+func ParseCidr(cidr string) ([]byte, Mask, error) {
+	ip, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, Mask{}, err
+	}
+
+	if ip.To4() == nil {
+		return nil, Mask{}, errors.New("only IPv4 networks are supported")
+	}
+
+	ones, _ := ipNet.Mask.Size()
+
+	return []byte(ip.To4()), CidrToMask(uint8(ones)), nil
+}
+
+// PrefixForHosts returns the smallest prefix able to contain the given hosts, keeping reserved addresses out of every subnet
+func PrefixForHosts(hosts uint64, reserved uint64) (uint8, error) {
+	if hosts == 0 {
+		return 0, errors.New("the hosts quantity must be greater than 0")
+	}
+
+	// Compared before the sum, which could wrap around
+	if reserved > 1<<32 || hosts > 1<<32-reserved {
+		return 0, fmt.Errorf("%d hosts don't fit in the whole IPv4 address space", hosts)
+	}
+	needed := hosts + reserved
+
+	// Number of host bits needed to address every host plus the reserved ones
+	hostBits := bits.Len64(needed - 1)
+
+	return uint8(32 - hostBits), nil
+}
+
+// PlanHosts finds the smallest network for the given hosts allowed by the provider, optionally fitting it in the within network (empty to skip)
+func PlanHosts(hosts uint64, provider Provider, within string) (HostsPlan, error) {
+	var plan HostsPlan
+
+	prefix, err := PrefixForHosts(hosts, provider.Reserved())
+	if err != nil {
+		return plan, err
+	}
+
+	// Use the smallest subnet allowed by the provider if the hosts need less
+	if prefix > provider.MaxPrefix {
+		prefix = provider.MaxPrefix
+	}
+
+	if err = provider.CheckPrefix(prefix); err != nil {
+		return plan, err
+	}
+
+	plan.Hosts = hosts
+	plan.Provider = provider
+	plan.Netmask = CidrToMask(prefix)
+	plan.BlockSize = 1 << (32 - uint64(prefix))
+	plan.Usable = plan.BlockSize - provider.Reserved()
+
+	plan.Wildcard = make([]uint8, 0)
+	for _, maskPart := range plan.Netmask.Dotted {
+		plan.Wildcard = append(plan.Wildcard, uint8(255)-maskPart)
+	}
+
+	if within == "" {
+		return plan, nil
+	}
+
+	address, withinMask, err := ParseCidr(within)
+	if err != nil {
+		return plan, err
+	}
+
+	if prefix < withinMask.Decimal {
+		return plan, fmt.Errorf("a /%d doesn't fit in a /%d", prefix, withinMask.Decimal)
+	}
+
+	// The constraint network is aligned to its own prefix, so it is aligned to any longer prefix too
+	plan.Within = make([]uint8, 4)
+	for i := range address {
+		plan.Within[i] = address[i] & withinMask.Dotted[i]
+	}
+	plan.WithinMask = withinMask
+	plan.FirstBlock = plan.Within
+	plan.Blocks = 1 << (uint64(prefix) - uint64(withinMask.Decimal))
+
+	return plan, nil
+}
+
+// Block is an aligned IPv4 network
+type Block struct {
+	Network []uint8
+	Netmask Mask
+}
+
+// String returns the block in "network/prefix" notation
+func (block Block) String() string {
+	if len(block.Network) != 4 {
+		return ""
+	}
+
+	return ByteArrToStr(block.Network) + "/" + fmt.Sprint(block.Netmask.Decimal)
+}
+
+// Size returns the quantity of addresses in the block
+func (block Block) Size() uint64 {
+	return 1 << (32 - uint64(block.Netmask.Decimal))
+}
+
+// Overlaps tells if two blocks share at least an address
+func (block Block) Overlaps(other Block) bool {
+	// Compare the networks with the shorter of the two masks
+	mask := block.Netmask
+	if other.Netmask.Decimal < mask.Decimal {
+		mask = other.Netmask
+	}
+
+	for i := range mask.Dotted {
+		if block.Network[i]&mask.Dotted[i] != other.Network[i]&mask.Dotted[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ParseBlock parses an IPv4 "address/prefix" string to a Block, clearing the host bits
+func ParseBlock(cidr string) (Block, error) {
+	address, netmask, err := ParseCidr(cidr)
+	if err != nil {
+		return Block{}, err
+	}
+
+	networkArr := make([]uint8, 4)
+	for i := range address {
+		networkArr[i] = address[i] & netmask.Dotted[i]
+	}
+
+	return Block{networkArr, netmask}, nil
+}
+
+// AllocateBlocks carves aligned blocks with the given prefixes out of parent, biggest first, returning them in the input order
+func AllocateBlocks(parent Block, prefixes []uint8) ([]Block, error) {
+	// Sort the requests from the biggest block to the smallest one, so no space is wasted for the alignment
+	order := make([]int, len(prefixes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return prefixes[order[a]] < prefixes[order[b]]
+	})
+
+	blocks := make([]Block, len(prefixes))
+	cursor := uint64(IPToUint32(parent.Network))
+	end := cursor + parent.Size()
+
+	for _, i := range order {
+		if prefixes[i] < parent.Netmask.Decimal || prefixes[i] > 32 {
+			return nil, fmt.Errorf("a /%d doesn't fit in %s", prefixes[i], parent)
+		}
+
+		// Align the cursor to the block size
+		size := uint64(1) << (32 - uint64(prefixes[i]))
+		cursor = (cursor + size - 1) &^ (size - 1)
+		if cursor+size > end {
+			return nil, fmt.Errorf("not enough space in %s", parent)
+		}
+
+		blocks[i] = Block{Uint32ToIP(uint32(cursor)), CidrToMask(prefixes[i])}
+		cursor += size
+	}
+
+	return blocks, nil
+}
@@ -0,0 +1,121 @@
+package network
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+)
+
+// Seconds between the NTP epoch (1900) and the Unix epoch (1970)
+const ntpEpochOffset = 2208988800
+
+// IPToInt converts an address to its numeric value
+func IPToInt(ip net.IP) *big.Int {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+
+	return new(big.Int).SetBytes(ip)
+}
+
+// IntToIP converts a numeric value to an address of the given length in bytes (4 or 16)
+func IntToIP(value *big.Int, length int) net.IP {
+	ip := make(net.IP, length)
+	value.FillBytes(ip)
+
+	return ip
+}
+
+// ULAPrefix generates a RFC 4193 Unique Local /48 from a timestamp and a MAC address with the algorithm of section 3.2.2.
+// If mac is nil a random EUI-64 is used
+func ULAPrefix(mac net.HardwareAddr, timestamp time.Time) (Prefix6, error) {
+	// 64 bits NTP timestamp: seconds since 1900 and fraction of second
+	key := make([]byte, 16)
+	seconds := uint64(timestamp.Unix() + ntpEpochOffset)
+	fraction := (uint64(timestamp.Nanosecond()) << 32) / uint64(time.Second)
+	binary.BigEndian.PutUint64(key[0:8], seconds<<32|fraction)
+
+	// EUI-64 identifier
+	eui64 := key[8:16]
+	switch len(mac) {
+	case 0:
+		if _, err := rand.Read(eui64); err != nil {
+			return Prefix6{}, err
+		}
+	case 6:
+		// Insert FFFE in the middle of the MAC and flip the universal/local bit
+		copy(eui64[0:3], mac[0:3])
+		eui64[3], eui64[4] = 0xff, 0xfe
+		copy(eui64[5:8], mac[3:6])
+		eui64[0] ^= 0x02
+	case 8:
+		copy(eui64, mac)
+		eui64[0] ^= 0x02
+	default:
+		return Prefix6{}, errors.New("the MAC address must be an EUI-48 or an EUI-64")
+	}
+
+	// The global ID is made of the least significant 40 bits of the SHA-1 digest
+	digest := sha1.Sum(key)
+	prefix := make(net.IP, net.IPv6len)
+	prefix[0] = 0xfd // FC00::/7 with the L bit set
+	copy(prefix[1:6], digest[len(digest)-5:])
+
+	return Prefix6{prefix, 48}, nil
+}
+
+// RandomHosts picks quantity different random usable addresses of an IPv4 or IPv6 network.
+// The network and broadcast addresses of IPv4 and the subnet-router anycast address of IPv6 are never picked
+func RandomHosts(cidr string, quantity int) ([]net.IP, error) {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+
+	length := net.IPv6len
+	if ipNet.IP.To4() != nil {
+		length = net.IPv4len
+	}
+
+	ones, totalBits := ipNet.Mask.Size()
+	hostBits := totalBits - ones
+	size := new(big.Int).Lsh(big.NewInt(1), uint(hostBits))
+
+	// Offsets of the reserved addresses, /31 and /32 IPv4 networks and /127 and /128 IPv6 networks have none
+	reserved := make(map[string]bool)
+	if hostBits > 1 {
+		reserved["0"] = true
+		if length == net.IPv4len {
+			reserved[new(big.Int).Sub(size, big.NewInt(1)).String()] = true
+		}
+	}
+
+	usable := new(big.Int).Sub(size, big.NewInt(int64(len(reserved))))
+	if usable.Cmp(big.NewInt(int64(quantity))) < 0 {
+		return nil, fmt.Errorf("the network has only %s usable addresses", usable)
+	}
+
+	network := IPToInt(ipNet.IP)
+	hosts := make([]net.IP, 0, quantity)
+	picked := make(map[string]bool)
+	for len(hosts) < quantity {
+		offset, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return nil, err
+		}
+
+		if reserved[offset.String()] || picked[offset.String()] {
+			continue
+		}
+		picked[offset.String()] = true
+
+		hosts = append(hosts, IntToIP(offset.Add(offset, network), length))
+	}
+
+	return hosts, nil
+}
@@ -0,0 +1,166 @@
+package network
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RegexDialect describes the syntax of a regular expression engine
+type RegexDialect struct {
+	Name      string
+	Group     string // Opening of a non-capturing group, or of a plain group if the engine has no other
+	Digit     string // Class of a decimal digit
+	WordStart string // Boundary before the address, so it isn't the end of a longer number or address
+	WordEnd   string // Boundary after the address, a final dot is allowed for the addresses at the end of a sentence
+}
+
+// Dialects supported by AddressRegex. RE2 and POSIX ERE have no lookarounds, so their boundaries match a character
+var RegexDialects = map[string]RegexDialect{
+	"pcre": {"PCRE", "(?:", `\d`, `(?<![\d.])`, `(?!\.?\d)`},
+	"re2":  {"RE2", "(?:", `\d`, `(?:^|[^\d.])`, `(?:\.?(?:[^\d.]|$))`},
+	"grep": {"grep -E", "(", "[0-9]", "(^|[^0-9.])", `(\.?([^0-9.]|$))`},
+}
+
+// FindRegexDialect searches a dialect by name, "ere" and "posix" are aliases of grep
+func FindRegexDialect(name string) (RegexDialect, bool) {
+	switch strings.ToLower(name) {
+	case "ere", "posix", "egrep":
+		name = "grep"
+	}
+
+	dialect, found := RegexDialects[strings.ToLower(name)]
+	return dialect, found
+}
+
+// digitsPattern returns the pattern of the numbers from start to stop, which have the same length and differ only in
+// their last digits, like 100-199 or 250-255
+func (dialect RegexDialect) digitsPattern(start string, stop string) string {
+	var pattern strings.Builder
+	anyDigits := 0
+
+	for i := range start {
+		switch {
+		case start[i] == stop[i]:
+			pattern.WriteByte(start[i])
+		case start[i] == '0' && stop[i] == '9':
+			anyDigits++
+			pattern.WriteString(dialect.Digit)
+		default:
+			pattern.WriteString("[" + string(start[i]) + "-" + string(stop[i]) + "]")
+		}
+	}
+
+	// Repeated digit classes are shortened, like \d\d to \d{2}
+	if anyDigits > 1 {
+		return strings.Replace(pattern.String(), strings.Repeat(dialect.Digit, anyDigits), dialect.Digit+"{"+strconv.Itoa(anyDigits)+"}", 1)
+	}
+
+	return pattern.String()
+}
+
+// NumberRangeRegex returns the pattern of the decimal numbers from min to max, without leading zeros
+func (dialect RegexDialect) NumberRangeRegex(min int, max int) string {
+	if min == max {
+		return strconv.Itoa(min)
+	}
+
+	// Split the range where the quantity of digits or the varying digits change, like 7-9, 10-99, 100-199, 200-249
+	stops := map[int]bool{max: true}
+	minDigits := strconv.Itoa(min)
+	for nines := 1; ; nines++ {
+		// min with its last digits replaced by nines, like 123 to 129 and 199
+		stop, _ := strconv.Atoi(strings.Repeat("9", nines))
+		if nines < len(minDigits) {
+			stop, _ = strconv.Atoi(minDigits[:len(minDigits)-nines] + strings.Repeat("9", nines))
+		}
+		if stop < min || stop > max {
+			break
+		}
+		stops[stop] = true
+	}
+	for power := 10; ; power *= 10 {
+		// max+1 with its last digits replaced by zeros, minus one, like 255 to 249 and 199
+		stop := (max+1)/power*power - 1
+		if stop < min {
+			break
+		}
+		stops[stop] = true
+	}
+
+	sortedStops := make([]int, 0, len(stops))
+	for stop := range stops {
+		sortedStops = append(sortedStops, stop)
+	}
+	sort.Ints(sortedStops)
+
+	patterns := make([]string, 0, len(sortedStops))
+	start := min
+	for _, stop := range sortedStops {
+		patterns = append(patterns, dialect.digitsPattern(strconv.Itoa(start), strconv.Itoa(stop)))
+		start = stop + 1
+	}
+
+	if len(patterns) == 1 {
+		return patterns[0]
+	}
+	return dialect.Group + strings.Join(patterns, "|") + ")"
+}
+
+// octetsRegex returns the pattern of the dotted octets from first to last, which have the same length
+func (dialect RegexDialect) octetsRegex(first []uint8, last []uint8) string {
+	if len(first) == 1 {
+		return dialect.NumberRangeRegex(int(first[0]), int(last[0]))
+	}
+
+	if first[0] == last[0] {
+		return strconv.Itoa(int(first[0])) + `\.` + dialect.octetsRegex(first[1:], last[1:])
+	}
+
+	// The rest of the first and of the last octet can cover the whole range, joining them with the ones in the middle
+	lowFull, highFull := true, true
+	for i := 1; i < len(first); i++ {
+		lowFull = lowFull && first[i] == 0
+		highFull = highFull && last[i] == 255
+	}
+
+	zeros := make([]uint8, len(first)-1)
+	full := make([]uint8, len(first)-1)
+	for i := range full {
+		full[i] = 255
+	}
+
+	parts := make([]string, 0, 3)
+	middleFirst, middleLast := int(first[0])+1, int(last[0])-1
+	if lowFull {
+		middleFirst--
+	} else {
+		parts = append(parts, strconv.Itoa(int(first[0]))+`\.`+dialect.octetsRegex(first[1:], full))
+	}
+	if highFull {
+		middleLast++
+	}
+	if middleFirst <= middleLast {
+		parts = append(parts, dialect.NumberRangeRegex(middleFirst, middleLast)+`\.`+dialect.octetsRegex(zeros, full))
+	}
+	if !highFull {
+		parts = append(parts, strconv.Itoa(int(last[0]))+`\.`+dialect.octetsRegex(zeros, last[1:]))
+	}
+
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return dialect.Group + strings.Join(parts, "|") + ")"
+}
+
+// AddressRegex returns a regular expression matching exactly the IPv4 addresses from first to last written as text.
+// With word the expression finds them inside a longer text, otherwise it must match the whole text
+func (dialect RegexDialect) AddressRegex(first []uint8, last []uint8, word bool) string {
+	// Every alternation is inside a group, so the boundaries apply to the whole pattern
+	pattern := dialect.octetsRegex(first, last)
+
+	if word {
+		return dialect.WordStart + pattern + dialect.WordEnd
+	}
+	return "^" + pattern + "$"
+}
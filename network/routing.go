@@ -0,0 +1,421 @@
+package network
+
+import (
+	"errors"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Linux route types written before the destination by "ip route"
+var linuxRouteTypes = map[string]bool{"unicast": true, "local": true, "broadcast": true, "multicast": true, "anycast": true, "throw": true, "nat": true, "blackhole": true, "unreachable": true, "prohibit": true}
+
+// Linux route types and interfaces that drop the packets
+var discardTargets = map[string]bool{"blackhole": true, "unreachable": true, "prohibit": true, "null0": true, "discard": true, "reject": true}
+
+// Protocols of the first letter of the Cisco route codes
+var ciscoProtocols = map[byte]string{'C': "connected", 'L': "local", 'S': "static", 'R': "rip", 'B': "bgp", 'D': "eigrp", 'O': "ospf", 'i': "isis", 'M': "mobile", 'U': "per-user static", 'o': "odr", 'P': "periodic static", 'H': "nhrp", 'l': "lisp"}
+
+// Cisco administrative distance and metric, like [110/20]
+var ciscoMetricPattern = regexp.MustCompile(`^\[(\d+)/(\d+)\]$`)
+
+// Junos protocol and preference, like *[OSPF/10]
+var junosProtocolPattern = regexp.MustCompile(`^\*?\[([A-Za-z-]+)/(\d+)\]$`)
+
+// NextHop is a gateway and/or an outgoing interface of a route
+type NextHop struct {
+	Gateway   net.IP
+	Interface string
+}
+
+// String returns the next hop as "gateway (interface)"
+func (nextHop NextHop) String() string {
+	switch {
+	case nextHop.Gateway == nil:
+		return nextHop.Interface
+	case nextHop.Interface == "":
+		return nextHop.Gateway.String()
+	default:
+		return nextHop.Gateway.String() + " (" + nextHop.Interface + ")"
+	}
+}
+
+// Route is an entry of a routing table
+type Route struct {
+	Prefix   *net.IPNet
+	Protocol string    // Source of the route, like "static" or "ospf", empty if unknown
+	Metric   string    // Distance, preference and/or metric as written by the router
+	NextHops []NextHop // Empty for the routes without gateway and interface
+	Discard  bool      // True if the route drops the packets (blackhole, Null0...)
+	Line     int       // Line of the route in the parsed text
+}
+
+// Node of a binary trie, with the route of the prefix it represents if any
+type trieNode struct {
+	children [2]*trieNode
+	route    *Route
+}
+
+// RoutingTable finds the longest prefix match of an address between its routes
+type RoutingTable struct {
+	roots [2]*trieNode // IPv4 and IPv6 tries
+	size  int
+}
+
+// NewRoutingTable creates an empty routing table
+func NewRoutingTable() *RoutingTable {
+	return &RoutingTable{roots: [2]*trieNode{{}, {}}}
+}
+
+// Root of the trie of an address family, with the address in its real length
+func (table *RoutingTable) root(ip net.IP) (*trieNode, net.IP) {
+	if ip4 := ip.To4(); ip4 != nil {
+		return table.roots[0], ip4
+	}
+
+	return table.roots[1], ip.To16()
+}
+
+// Value of a bit of an address, starting from the most significant one
+func bitAt(ip net.IP, i int) int {
+	return int(ip[i/8]>>(7-uint(i%8))) & 1
+}
+
+// Insert adds a route to the table. It returns false, leaving the table unchanged, if its prefix is already there
+func (table *RoutingTable) Insert(route Route) bool {
+	// The family is the one of the mask, because IPv4-mapped IPv6 prefixes have an IPv4 address
+	node, ip := table.roots[1], route.Prefix.IP.To16()
+	length, bits := route.Prefix.Mask.Size()
+	if bits == 32 {
+		node, ip = table.roots[0], route.Prefix.IP.To4()
+	}
+
+	for i := 0; i < length; i++ {
+		bit := bitAt(ip, i)
+		if node.children[bit] == nil {
+			node.children[bit] = &trieNode{}
+		}
+		node = node.children[bit]
+	}
+
+	if node.route != nil {
+		return false
+	}
+
+	node.route = &route
+	table.size++
+	return true
+}
+
+// Lookup returns every route matching an address, from the least to the most specific one.
+// The last route is the longest prefix match, no routes mean the address is unreachable
+func (table *RoutingTable) Lookup(ip net.IP) []Route {
+	matches := make([]Route, 0)
+	node, ip := table.root(ip)
+
+	for i := 0; node != nil; i++ {
+		if node.route != nil {
+			matches = append(matches, *node.route)
+		}
+		if i == len(ip)*8 {
+			break
+		}
+		node = node.children[bitAt(ip, i)]
+	}
+
+	return matches
+}
+
+// Len returns the quantity of routes in the table
+func (table *RoutingTable) Len() int {
+	return table.size
+}
+
+// classfulLength returns the prefix length of the class of an IPv4 address
+func classfulLength(ip net.IP) int {
+	switch {
+	case ip[0] < 128:
+		return 8
+	case ip[0] < 192:
+		return 16
+	default:
+		return 24
+	}
+}
+
+// hostNet returns the single address prefix of an address
+func hostNet(ip net.IP) *net.IPNet {
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}
+	}
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+}
+
+// findDestination returns the position of the destination of a route among the fields of a line and its prefix.
+// subnetted is the prefix length of the Cisco "is subnetted" headers, for the classful entries written without it
+func findDestination(fields []string, subnetted map[string]int) (int, *net.IPNet) {
+	for i, field := range fields {
+		// Destination with prefix length, the most common case
+		if _, ipNet, err := net.ParseCIDR(field); err == nil {
+			return i, ipNet
+		}
+
+		if i > 0 && (fields[i-1] == "via" || fields[i-1] == "to" || fields[i-1] == "src" || fields[i-1] == "from") {
+			continue
+		}
+
+		if field == "default" {
+			// The family of a default route is the one of its gateway
+			for _, other := range fields[i+1:] {
+				if gateway := net.ParseIP(other); gateway != nil && gateway.To4() == nil {
+					return i, &net.IPNet{IP: net.IPv6zero, Mask: net.CIDRMask(0, 128)}
+				}
+			}
+			return i, &net.IPNet{IP: net.IPv4zero.To4(), Mask: net.CIDRMask(0, 32)}
+		}
+
+		ip := net.ParseIP(field)
+		if ip == nil {
+			if i > 3 {
+				break
+			}
+			continue
+		}
+
+		// Linux and plain host routes are written without prefix length
+		if i == 0 || linuxRouteTypes[fields[i-1]] {
+			return i, hostNet(ip)
+		}
+
+		// Cisco classful entries: the length is in the "is subnetted" header or is the one of the class
+		if ip4 := ip.To4(); ip4 != nil && i <= 3 {
+			length, found := subnetted[ip4.Mask(net.CIDRMask(classfulLength(ip4), 32)).String()]
+			if !found {
+				length = classfulLength(ip4)
+			}
+			return i, &net.IPNet{IP: ip4.Mask(net.CIDRMask(length, 32)), Mask: net.CIDRMask(length, 32)}
+		}
+
+		break
+	}
+
+	return -1, nil
+}
+
+// parseNextHops reads the gateways, interfaces and attributes of a route from the fields after its destination
+func parseNextHops(route *Route, line string, fields []string) {
+	var nextHop NextHop
+
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		next := ""
+		if i+1 < len(fields) {
+			next = fields[i+1]
+		}
+
+		switch {
+		case field == "via" || field == "to":
+			if gateway := net.ParseIP(next); gateway != nil {
+				nextHop.Gateway = gateway
+			} else if next != "" {
+				nextHop.Interface = next
+			}
+			i++
+		case field == "dev":
+			nextHop.Interface = next
+			i++
+		case field == "proto":
+			route.Protocol = next
+			i++
+		case field == "metric":
+			// Junos writes the metric after the preference
+			if route.Metric != "" {
+				route.Metric += "/"
+			}
+			route.Metric += next
+			i++
+		case field == "connected" && route.Protocol == "":
+			route.Protocol = "connected"
+		case ciscoMetricPattern.MatchString(field):
+			route.Metric = strings.Trim(field, "[]")
+		case junosProtocolPattern.MatchString(field):
+			match := junosProtocolPattern.FindStringSubmatch(field)
+			route.Protocol = strings.ToLower(match[1])
+			route.Metric = match[2]
+		case i == 0 && net.ParseIP(field) != nil:
+			// Plain "prefix nexthop [interface]" lines
+			nextHop.Gateway = net.ParseIP(field)
+			if next != "" && net.ParseIP(next) == nil {
+				nextHop.Interface = next
+			}
+		}
+	}
+
+	// Cisco writes the interface after the last comma, following the age of the route
+	if parts := strings.Split(line, ","); nextHop.Interface == "" && len(parts) > 1 {
+		last := strings.Fields(parts[len(parts)-1])
+		if len(last) == 1 && !strings.Contains(last[0], ":") && net.ParseIP(last[0]) == nil {
+			nextHop.Interface = last[0]
+		}
+	}
+
+	// Plain "prefix interface" lines
+	if nextHop.Gateway == nil && nextHop.Interface == "" && len(fields) == 1 && route.Protocol == "" {
+		nextHop.Interface = fields[0]
+	}
+
+	if discardTargets[strings.ToLower(nextHop.Interface)] || discardTargets[route.Protocol] {
+		route.Discard = true
+	}
+
+	if nextHop.Gateway != nil || nextHop.Interface != "" {
+		route.NextHops = append(route.NextHops, nextHop)
+	}
+}
+
+// parseSubnetted reads the Cisco classful headers, like "10.0.0.0/24 is subnetted, 4 subnets", saving the length of
+// the entries of the class written without it. Returns false if the line isn't a header
+func parseSubnetted(line string, fields []string, subnetted map[string]int) bool {
+	if !strings.Contains(line, "subnetted") {
+		return false
+	}
+
+	if _, ipNet, err := net.ParseCIDR(fields[0]); err == nil && !strings.Contains(line, "variably") {
+		length, _ := ipNet.Mask.Size()
+		ip4 := ipNet.IP.To4()
+		if ip4 != nil {
+			subnetted[ip4.Mask(net.CIDRMask(classfulLength(ip4), 32)).String()] = length
+		}
+	}
+
+	return true
+}
+
+// RoutePrefixes returns every prefix of a text, like a list of prefixes or the output of "show ip route connected".
+// The Cisco classful entries written without length take it from their "is subnetted" header, the default routes
+// and the summary headers are skipped
+func RoutePrefixes(text string) []*net.IPNet {
+	prefixes := make([]*net.IPNet, 0)
+	subnetted := make(map[string]int)
+
+	for _, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(strings.ReplaceAll(line, ",", " "))
+		if len(fields) == 0 || strings.EqualFold(fields[0], "gateway") || strings.HasPrefix(fields[0], "Codes:") {
+			continue
+		}
+
+		if parseSubnetted(line, fields, subnetted) {
+			continue
+		}
+
+		// A line can contain many prefixes with their length, otherwise it's a route written without it
+		found := false
+		for _, field := range fields {
+			if _, ipNet, err := net.ParseCIDR(field); err == nil {
+				prefixes = append(prefixes, ipNet)
+				found = true
+			}
+		}
+
+		if !found {
+			if position, destination := findDestination(fields, subnetted); destination != nil && fields[position] != "default" {
+				prefixes = append(prefixes, destination)
+			}
+		}
+	}
+
+	return prefixes
+}
+
+// ParseRoutingTable builds a routing table from plain "prefix nexthop" lines or from the output of Linux "ip route",
+// Cisco "show ip route" or Junos "show route". Only the first route of a repeated prefix is kept, like the routers do
+func ParseRoutingTable(text string) (*RoutingTable, error) {
+	table := NewRoutingTable()
+	subnetted := make(map[string]int)
+	var last *Route
+	skipHops := false
+
+	for i, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(strings.ReplaceAll(line, ",", " "))
+		if len(fields) == 0 || strings.EqualFold(fields[0], "gateway") || strings.HasPrefix(fields[0], "Codes:") {
+			continue
+		}
+
+		if parseSubnetted(line, fields, subnetted) {
+			continue
+		}
+
+		position, destination := findDestination(fields, subnetted)
+		if destination == nil {
+			// Next hops written on their own line: Cisco equal cost paths and Junos next hops.
+			// Junos inactive routes (like "[BGP/170]" without "*") are skipped with their next hops
+			if strings.HasPrefix(fields[0], "[") && !ciscoMetricPattern.MatchString(fields[0]) {
+				skipHops = true
+			} else if last != nil && !skipHops && (strings.Contains(line, "via") || strings.Contains(line, " to ")) {
+				parseNextHops(last, line, fields)
+			}
+			continue
+		}
+
+		route := Route{Prefix: destination, Line: i + 1}
+		skipHops = false
+		if position > 0 && linuxRouteTypes[fields[position-1]] {
+			route.Discard = discardTargets[fields[position-1]]
+			route.Protocol = fields[position-1]
+		} else if position > 0 && ciscoProtocols[fields[0][0]] != "" {
+			route.Protocol = ciscoProtocols[fields[0][0]]
+		}
+		parseNextHops(&route, line, fields[position+1:])
+
+		// The route is inserted at the end, when all its next hops have been read
+		if last != nil {
+			table.Insert(*last)
+		}
+		last = &route
+	}
+
+	if last != nil {
+		table.Insert(*last)
+	}
+
+	if table.Len() == 0 {
+		return nil, errors.New("no routes found")
+	}
+
+	return table, nil
+}
+
+// Describe returns the route as a single line, like "10.0.0.0/8 via 192.168.1.1 (eth0) [static, 1/0]"
+func (route Route) Describe() string {
+	text := route.Prefix.String()
+
+	if route.Discard {
+		text += " discard"
+	} else if len(route.NextHops) == 0 {
+		text += " directly connected"
+	}
+
+	for i, nextHop := range route.NextHops {
+		if i == 0 {
+			text += " via "
+		} else {
+			text += ", "
+		}
+		text += nextHop.String()
+	}
+
+	details := make([]string, 0, 2)
+	if route.Protocol != "" {
+		details = append(details, route.Protocol)
+	}
+	if route.Metric != "" {
+		details = append(details, route.Metric)
+	}
+	if len(details) > 0 {
+		text += " [" + strings.Join(details, ", ") + "]"
+	}
+
+	return text + " (line " + strconv.Itoa(route.Line) + ")"
+}
@@ -0,0 +1,53 @@
+package network
+
+import (
+	"testing"
+)
+
+// Output of "show ip route connected" of IOS 15, with classful entries written without their length
+const iosConnectedRoutes = `Codes: L - local, C - connected, S - static, R - RIP, M - mobile, B - BGP
+       D - EIGRP, EX - EIGRP external, O - OSPF, IA - OSPF inter area
+       N1 - OSPF NSSA external type 1, N2 - OSPF NSSA external type 2
+       E1 - OSPF external type 1, E2 - OSPF external type 2
+       i - IS-IS, su - IS-IS summary, L1 - IS-IS level-1, L2 - IS-IS level-2
+       ia - IS-IS inter area, * - candidate default, U - per-user static route
+       o - ODR, P - periodic downloaded static route, H - NHRP, l - LISP
+       + - replicated route, % - next hop override
+
+Gateway of last resort is 10.1.1.254 to network 0.0.0.0
+
+      10.0.0.0/24 is subnetted, 2 subnets
+C        10.1.1.0 is directly connected, GigabitEthernet0/0
+C        10.1.2.0 is directly connected, GigabitEthernet0/1
+      172.16.0.0/16 is variably subnetted, 2 subnets, 2 masks
+C        172.16.1.0/24 is directly connected, GigabitEthernet0/2
+L        172.16.1.1/32 is directly connected, GigabitEthernet0/2
+C     192.168.10.0/24 is directly connected, Vlan10
+`
+
+func TestRoutePrefixes(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"ios connected", iosConnectedRoutes, []string{"10.1.1.0/24", "10.1.2.0/24", "172.16.1.0/24", "172.16.1.1/32", "192.168.10.0/24"}},
+		{"list", "10.0.0.0/24 10.0.1.0/24\n2001:db8::/48", []string{"10.0.0.0/24", "10.0.1.0/24", "2001:db8::/48"}},
+		{"default route", "S*    0.0.0.0/0 [1/0] via 10.1.1.254\ndefault via 10.1.1.254 dev eth0", []string{"0.0.0.0/0"}},
+	}
+
+	for _, test := range tests {
+		prefixes := RoutePrefixes(test.text)
+		if len(prefixes) != len(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, prefixes, test.want)
+			continue
+		}
+
+		for i := range prefixes {
+			if prefixes[i].String() != test.want[i] {
+				t.Errorf("%s: got %v, want %v", test.name, prefixes, test.want)
+				break
+			}
+		}
+	}
+}
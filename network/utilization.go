@@ -0,0 +1,194 @@
+package network
+
+import (
+	"math/big"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Candidate addresses inside any text, validated by net.ParseIP
+var addressPattern = regexp.MustCompile(`[0-9A-Fa-f]*:[0-9A-Fa-f:.]+|\d{1,3}(?:\.\d{1,3}){3}`)
+
+// UtilizationReport contains the result of the Utilization function
+type UtilizationReport struct {
+	Network    *net.IPNet
+	Usable     *big.Int     // Quantity of usable addresses of the network
+	Used       int          // Quantity of different usable addresses in use
+	Percentage float64      // Percentage of the usable addresses in use
+	Free       []*net.IPNet // Free ranges as aligned prefixes
+	Outside    []net.IP     // Addresses outside of the network
+	Reserved   []net.IP     // Network and broadcast (IPv4) or subnet-router anycast (IPv6) addresses in use
+}
+
+// ExtractAddresses returns every IPv4 and IPv6 address found in a text, like an ARP table or a DHCP leases dump
+func ExtractAddresses(text string) []net.IP {
+	addresses := make([]net.IP, 0)
+
+	for _, match := range findAddresses(addressPattern, text) {
+		if ip := parseAddress(match); ip != nil {
+			addresses = append(addresses, ip)
+		}
+	}
+
+	return addresses
+}
+
+// findAddresses returns the matches of a pattern of addresses, skipping the ones inside a longer dotted number like an
+// OID (1.3.6.1.4.1.9) or a version (1234.5.6.7). RE2 has no lookarounds, so the characters around are checked here
+func findAddresses(pattern *regexp.Regexp, text string) []string {
+	isDigit := func(i int) bool {
+		return i >= 0 && i < len(text) && text[i] >= '0' && text[i] <= '9'
+	}
+
+	matches := make([]string, 0)
+	for _, index := range pattern.FindAllStringIndex(text, -1) {
+		start, end := index[0], index[1]
+		if isDigit(start-1) || (start > 0 && text[start-1] == '.') {
+			continue
+		}
+		// A dot after the address is allowed at the end of a sentence
+		if isDigit(end) || (end < len(text) && text[end] == '.' && isDigit(end+1)) {
+			continue
+		}
+
+		matches = append(matches, text[start:end])
+	}
+
+	return matches
+}
+
+// parseAddress parses an address found in a text, dropping the punctuation of the sentence after it only if needed,
+// because "::" can start or end an IPv6 address
+func parseAddress(match string) net.IP {
+	if ip := net.ParseIP(match); ip != nil {
+		return ip
+	}
+
+	return net.ParseIP(strings.TrimRight(match, ".,"))
+}
+
+// RangeToIPNets splits an inclusive range of addresses of the same family in the fewest aligned prefixes
+func RangeToIPNets(first net.IP, last net.IP) []*net.IPNet {
+	length := net.IPv6len
+	if first.To4() != nil && last.To4() != nil {
+		length = net.IPv4len
+	}
+	totalBits := uint(length * 8)
+
+	ipNets := make([]*net.IPNet, 0)
+	start := IPToInt(first)
+	end := IPToInt(last)
+	one := big.NewInt(1)
+
+	for start.Cmp(end) <= 0 {
+		// Biggest block aligned to start that doesn't go after end
+		hostBits := start.TrailingZeroBits()
+		if start.Sign() == 0 || hostBits > totalBits {
+			hostBits = totalBits
+		}
+
+		blockLast := new(big.Int)
+		for {
+			blockLast.Lsh(one, hostBits)
+			blockLast.Add(blockLast, start)
+			blockLast.Sub(blockLast, one)
+			if blockLast.Cmp(end) <= 0 {
+				break
+			}
+			hostBits--
+		}
+
+		ipNets = append(ipNets, &net.IPNet{IP: IntToIP(start, length), Mask: net.CIDRMask(int(totalBits-hostBits), int(totalBits))})
+		start = blockLast.Add(blockLast, one)
+	}
+
+	return ipNets
+}
+
+// Utilization reports how many usable addresses of a network are in use, and which ranges are still free
+func Utilization(cidr string, addresses []net.IP) (UtilizationReport, error) {
+	var report UtilizationReport
+
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return report, err
+	}
+	report.Network = ipNet
+
+	length := net.IPv6len
+	if ipNet.IP.To4() != nil {
+		length = net.IPv4len
+	}
+
+	ones, totalBits := ipNet.Mask.Size()
+	hostBits := uint(totalBits - ones)
+	one := big.NewInt(1)
+
+	// Usable range: IPv4 networks lose the network and broadcast addresses, IPv6 ones the subnet-router anycast address
+	first := IPToInt(ipNet.IP)
+	last := new(big.Int).Lsh(one, hostBits)
+	last.Add(last, first).Sub(last, one)
+	reserved := make([]*big.Int, 0, 2)
+	if hostBits > 1 {
+		reserved = append(reserved, new(big.Int).Set(first))
+		first = new(big.Int).Add(first, one)
+		if length == net.IPv4len {
+			reserved = append(reserved, new(big.Int).Set(last))
+			last = new(big.Int).Sub(last, one)
+		}
+	}
+	report.Usable = new(big.Int).Sub(last, first)
+	report.Usable.Add(report.Usable, one)
+
+	// Classify every address, counting the repeated ones once
+	seen := make(map[string]bool)
+	used := make([]*big.Int, 0)
+	report.Outside = make([]net.IP, 0)
+	report.Reserved = make([]net.IP, 0)
+	for _, address := range addresses {
+		if seen[address.String()] {
+			continue
+		}
+		seen[address.String()] = true
+
+		if (address.To4() != nil) != (length == net.IPv4len) || !ipNet.Contains(address) {
+			report.Outside = append(report.Outside, address)
+			continue
+		}
+
+		value := IPToInt(address)
+		isReserved := false
+		for _, reservedValue := range reserved {
+			if value.Cmp(reservedValue) == 0 {
+				isReserved = true
+			}
+		}
+
+		if isReserved {
+			report.Reserved = append(report.Reserved, address)
+		} else {
+			used = append(used, value)
+		}
+	}
+
+	report.Used = len(used)
+	report.Percentage, _ = new(big.Float).Quo(new(big.Float).SetInt64(int64(len(used)*100)), new(big.Float).SetInt(report.Usable)).Float64()
+
+	// The free ranges are the gaps between the sorted used addresses
+	sort.Slice(used, func(a, b int) bool {
+		return used[a].Cmp(used[b]) < 0
+	})
+
+	report.Free = make([]*net.IPNet, 0)
+	cursor := first
+	for _, value := range append(used, new(big.Int).Add(last, one)) {
+		if value.Cmp(cursor) > 0 {
+			report.Free = append(report.Free, RangeToIPNets(IntToIP(cursor, length), IntToIP(new(big.Int).Sub(value, one), length))...)
+		}
+		cursor = new(big.Int).Add(value, one)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,367 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package packet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Field is a decoded field of a packet, the headers have their fields as children
+type Field struct {
+	Name     string
+	Value    string
+	Children []*Field
+}
+
+// Names of the EtherTypes
+var etherTypes = map[uint16]string{
+	0x0800: "IPv4",
+	0x0806: "ARP",
+	0x8100: "802.1Q VLAN",
+	0x88a8: "802.1ad QinQ",
+	0x86dd: "IPv6",
+	0x8847: "MPLS",
+	0x88cc: "LLDP",
+}
+
+// Names of the IP protocols, also used as IPv6 next headers
+var protocols = map[uint8]string{
+	0:   "IPv6 Hop-by-Hop Options",
+	1:   "ICMP",
+	2:   "IGMP",
+	4:   "IPv4",
+	6:   "TCP",
+	17:  "UDP",
+	41:  "IPv6",
+	43:  "IPv6 Routing",
+	44:  "IPv6 Fragment",
+	47:  "GRE",
+	50:  "ESP",
+	51:  "AH",
+	58:  "ICMPv6",
+	59:  "IPv6 No Next Header",
+	60:  "IPv6 Destination Options",
+	89:  "OSPF",
+	112: "VRRP",
+	132: "SCTP",
+}
+
+// line returns the name and the value of a field
+func (field *Field) line() string {
+	if field.Value == "" {
+		return field.Name
+	}
+
+	return field.Name + ": " + field.Value
+}
+
+// writeTree writes the children of a field as the branches of a tree
+func writeTree(builder *strings.Builder, children []*Field, indent string) {
+	for i, child := range children {
+		branch, nextIndent := "├─ ", "│  "
+		if i == len(children)-1 {
+			branch, nextIndent = "└─ ", "   "
+		}
+
+		builder.WriteString(indent + branch + child.line() + "\n")
+		writeTree(builder, child.Children, indent+nextIndent)
+	}
+}
+
+// Format returns the layers of a packet as a tree of fields
+func Format(layers []*Field) string {
+	var builder strings.Builder
+	for _, layer := range layers {
+		builder.WriteString(layer.line() + "\n")
+		writeTree(&builder, layer.Children, "")
+	}
+
+	return strings.TrimRight(builder.String(), "\n")
+}
+
+// add appends a child field and returns it
+func (field *Field) add(name string, format string, values ...interface{}) *Field {
+	child := &Field{Name: name, Value: fmt.Sprintf(format, values...)}
+	field.Children = append(field.Children, child)
+	return child
+}
+
+// truncated marks a header shorter than its minimum length and returns the layers decoded so far
+func truncated(layer *Field, length int, minimum int) []*Field {
+	layer.add("Truncated", "%d bytes captured, at least %d needed", length, minimum)
+	return []*Field{layer}
+}
+
+// protocolName returns the name of an IP protocol with its number
+func protocolName(protocol uint8) string {
+	if name, found := protocols[protocol]; found {
+		return fmt.Sprintf("%d (%s)", protocol, name)
+	}
+
+	return fmt.Sprintf("%d", protocol)
+}
+
+// Decode returns the layers of a packet, from an Ethernet frame or from an IPv4 or IPv6 header
+func Decode(data []byte) ([]*Field, error) {
+	if len(data) == 0 {
+		return nil, errors.New("the packet is empty")
+	}
+
+	// The captures of tcpdump start from the IP header, the ones of Wireshark from the Ethernet one. A MAC address can
+	// start like an IP header, so a frame carrying IP comes first and a raw IP packet must fill the captured bytes
+	version := data[0] >> 4
+	switch {
+	case ethernetIP(data):
+		return decodeEthernet(data), nil
+	case version == 4 && len(data) >= 20 && data[0]&0x0f >= 5 && int(binary.BigEndian.Uint16(data[2:])) == len(data):
+		return decodeIPv4(data), nil
+	case version == 6 && len(data) >= 40 && int(binary.BigEndian.Uint16(data[4:]))+40 == len(data):
+		return decodeIPv6(data), nil
+	case len(data) >= 14 && etherTypes[binary.BigEndian.Uint16(data[12:])] != "":
+		return decodeEthernet(data), nil
+	}
+
+	return nil, errors.New("the bytes aren't an Ethernet frame or an IPv4 or IPv6 packet")
+}
+
+// ethernetIP tells if the bytes are an Ethernet frame carrying, after its VLAN tags, an IP header of the version
+// matching the EtherType
+func ethernetIP(data []byte) bool {
+	for offset := 12; len(data) > offset+2; offset += 4 {
+		switch binary.BigEndian.Uint16(data[offset:]) {
+		case 0x0800:
+			return data[offset+2]>>4 == 4
+		case 0x86dd:
+			return data[offset+2]>>4 == 6
+		case 0x8100, 0x88a8:
+			continue
+		}
+		return false
+	}
+
+	return false
+}
+
+// decodeEthernet decodes an Ethernet II frame with its VLAN tags
+func decodeEthernet(data []byte) []*Field {
+	layer := &Field{Name: "Ethernet II"}
+	layer.add("Destination", "%s", net.HardwareAddr(data[0:6]))
+	layer.add("Source", "%s", net.HardwareAddr(data[6:12]))
+
+	layers := []*Field{layer}
+	etherType, offset := binary.BigEndian.Uint16(data[12:]), 14
+	for etherType == 0x8100 || etherType == 0x88a8 {
+		if len(data) < offset+4 {
+			return append(layers, truncated(&Field{Name: etherTypes[etherType]}, len(data)-offset, 4)...)
+		}
+
+		tag := binary.BigEndian.Uint16(data[offset:])
+		vlan := &Field{Name: etherTypes[etherType]}
+		vlan.add("Priority (PCP)", "%d", tag>>13)
+		vlan.add("Drop eligible (DEI)", "%d", tag>>12&1)
+		vlan.add("VLAN ID", "%d", tag&0x0fff)
+		layers = append(layers, vlan)
+
+		etherType, offset = binary.BigEndian.Uint16(data[offset+2:]), offset+4
+	}
+
+	typeName := fmt.Sprintf("0x%04x", etherType)
+	if name, found := etherTypes[etherType]; found {
+		typeName += " (" + name + ")"
+	}
+	layers[len(layers)-1].add("Type", "%s", typeName)
+
+	switch etherType {
+	case 0x0800:
+		return append(layers, decodeIPv4(data[offset:])...)
+	case 0x86dd:
+		return append(layers, decodeIPv6(data[offset:])...)
+	}
+
+	layer.add("Payload", "%d bytes, not decoded", len(data)-offset)
+	return layers
+}
+
+// decodeIPv4 decodes an IPv4 header (RFC 791) and its payload
+func decodeIPv4(data []byte) []*Field {
+	layer := &Field{Name: "IPv4"}
+	if len(data) < 20 {
+		return truncated(layer, len(data), 20)
+	}
+
+	headerLength := int(data[0]&0x0f) * 4
+	totalLength := int(binary.BigEndian.Uint16(data[2:]))
+	flags := data[6] >> 5
+	fragmentOffset := int(binary.BigEndian.Uint16(data[6:]) & 0x1fff)
+	protocol := data[9]
+
+	layer.add("Version", "%d", data[0]>>4)
+	layer.add("Header length", "%d bytes (%d)", headerLength, headerLength/4)
+	layer.add("DSCP", "%d", data[1]>>2)
+	layer.add("ECN", "%d", data[1]&0x03)
+	totalField := layer.add("Total length", "%d", totalLength)
+	layer.add("Identification", "0x%04x (%d)", binary.BigEndian.Uint16(data[4:]), binary.BigEndian.Uint16(data[4:]))
+	flagNames := make([]string, 0, 2)
+	if flags&0x2 != 0 {
+		flagNames = append(flagNames, "DF")
+	}
+	if flags&0x1 != 0 {
+		flagNames = append(flagNames, "MF")
+	}
+	if flags&0x4 != 0 {
+		flagNames = append(flagNames, "reserved bit set")
+	}
+	layer.add("Flags", "%s", strings.TrimSuffix(fmt.Sprintf("0x%x (%s)", flags, strings.Join(flagNames, ", ")), " ()"))
+	layer.add("Fragment offset", "%d (%d bytes)", fragmentOffset, fragmentOffset*8)
+	layer.add("TTL", "%d", data[8])
+	layer.add("Protocol", "%s", protocolName(protocol))
+
+	if headerLength < 20 || headerLength > len(data) {
+		layer.add("Header checksum", "0x%04x (not verified, invalid header length)", binary.BigEndian.Uint16(data[10:]))
+		return []*Field{layer}
+	}
+	header := append([]byte(nil), data[:headerLength]...)
+	header[10], header[11] = 0, 0
+	checksumField(layer, "Header checksum", binary.BigEndian.Uint16(data[10:]), checksum(header), "")
+
+	layer.add("Source", "%s", net.IP(data[12:16]))
+	layer.add("Destination", "%s", net.IP(data[16:20]))
+	if headerLength > 20 {
+		layer.add("Options", "%d bytes", headerLength-20)
+	}
+
+	// The frames can have a padding after the packet, and the capture can be cut before its end
+	end := totalLength
+	if end > len(data) {
+		end = len(data)
+		totalField.Value += fmt.Sprintf(" (%d bytes captured)", len(data))
+	} else if end < len(data) {
+		layer.add("Trailer", "%d bytes after the packet, like the Ethernet padding", len(data)-end)
+	}
+	if end < headerLength {
+		return []*Field{layer}
+	}
+
+	if fragmentOffset > 0 {
+		layer.add("Payload", "%d bytes of a fragment, the %s header is in the first fragment", end-headerLength, protocols[protocol])
+		return []*Field{layer}
+	}
+
+	upper := upperLayer{
+		protocol: protocol,
+		pseudo: func(length int) []byte {
+			pseudo := make([]byte, 12)
+			copy(pseudo, data[12:20])
+			pseudo[9] = protocol
+			binary.BigEndian.PutUint16(pseudo[10:], uint16(length))
+			return pseudo
+		},
+		length: totalLength - headerLength,
+	}
+	if flags&0x1 != 0 {
+		upper.incomplete = "the packet is a fragment"
+	}
+
+	return append([]*Field{layer}, upper.decode(data[headerLength:end])...)
+}
+
+// decodeIPv6 decodes an IPv6 header (RFC 8200), its extension headers and its payload
+func decodeIPv6(data []byte) []*Field {
+	layer := &Field{Name: "IPv6"}
+	if len(data) < 40 {
+		return truncated(layer, len(data), 40)
+	}
+
+	payloadLength := int(binary.BigEndian.Uint16(data[4:]))
+	next := data[6]
+
+	layer.add("Version", "%d", data[0]>>4)
+	layer.add("Traffic class", "0x%02x (DSCP %d, ECN %d)", binary.BigEndian.Uint16(data)>>4&0xff, binary.BigEndian.Uint16(data)>>6&0x3f, binary.BigEndian.Uint16(data)>>4&0x03)
+	layer.add("Flow label", "0x%05x", binary.BigEndian.Uint32(data)&0xfffff)
+	payloadField := layer.add("Payload length", "%d", payloadLength)
+	layer.add("Next header", "%s", protocolName(next))
+	layer.add("Hop limit", "%d", data[7])
+	layer.add("Source", "%s", net.IP(data[8:24]))
+	layer.add("Destination", "%s", net.IP(data[24:40]))
+
+	end := 40 + payloadLength
+	if end > len(data) {
+		end = len(data)
+		payloadField.Value += fmt.Sprintf(" (%d bytes captured)", len(data)-40)
+	} else if end < len(data) {
+		layer.add("Trailer", "%d bytes after the packet, like the Ethernet padding", len(data)-end)
+	}
+
+	// Extension headers, until the upper layer
+	layers := []*Field{layer}
+	offset, incomplete := 40, ""
+	for next == 0 || next == 43 || next == 44 || next == 51 || next == 60 {
+		extension := &Field{Name: protocols[next]}
+		if end < offset+8 {
+			return append(layers, truncated(extension, end-offset, 8)...)
+		}
+
+		length := (int(data[offset+1]) + 1) * 8
+		switch next {
+		case 44:
+			length = 8
+			fragmentOffset := binary.BigEndian.Uint16(data[offset+2:]) >> 3
+			extension.add("Fragment offset", "%d (%d bytes)", fragmentOffset, int(fragmentOffset)*8)
+			extension.add("More fragments", "%t", data[offset+3]&0x1 != 0)
+			extension.add("Identification", "0x%08x", binary.BigEndian.Uint32(data[offset+4:]))
+			if fragmentOffset > 0 {
+				extension.add("Payload", "%d bytes of a fragment, the upper layer header is in the first fragment", end-offset-8)
+				return append(layers, extension)
+			}
+			if data[offset+3]&0x1 != 0 {
+				incomplete = "the packet is a fragment"
+			}
+		case 51:
+			length = (int(data[offset+1]) + 2) * 4
+			extension.add("SPI", "0x%08x", binary.BigEndian.Uint32(data[offset+4:]))
+		default:
+			extension.add("Length", "%d bytes", length)
+		}
+		extension.add("Next header", "%s", protocolName(data[offset]))
+		layers = append(layers, extension)
+
+		next, offset = data[offset], offset+length
+		if offset > end {
+			extension.add("Truncated", "the header is longer than the packet")
+			return layers
+		}
+	}
+
+	upper := upperLayer{
+		protocol: next,
+		ipv6:     true,
+		pseudo: func(length int) []byte {
+			pseudo := make([]byte, 40)
+			copy(pseudo, data[8:40])
+			binary.BigEndian.PutUint32(pseudo[32:], uint32(length))
+			pseudo[39] = next
+			return pseudo
+		},
+		length:     40 + payloadLength - offset,
+		incomplete: incomplete,
+	}
+
+	return append(layers, upper.decode(data[offset:end])...)
+}
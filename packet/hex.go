@@ -0,0 +1,117 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package packet
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Offset at the start of the lines of a dump, like "0x0010:" of tcpdump -X or "0010  " of the Wireshark hex dump
+var offsetPattern = regexp.MustCompile(`^\s*(?:0x)?([0-9a-fA-F]{4,8})(?::\s*|\s{2,})`)
+
+// Bytes in every line of a dump
+const dumpLineBytes = 16
+
+// Separators of the bytes removed from the hex text
+var hexCleaner = strings.NewReplacer(" ", "", "\t", "", "\r", "", ":", "", "-", "", "0x", "", "\\x", "")
+
+// ParseHex reads the bytes of a packet written as a hex stream (Wireshark "copy as hex stream"), as spaced or colon
+// separated bytes, or as a dump with offsets and ASCII column like the ones of tcpdump -X, xxd and hexdump -C
+func ParseHex(text string) ([]byte, error) {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+
+	// The lines of a dump start with their offset, the others (like the packet summary of tcpdump) are skipped
+	dump := false
+	for _, line := range lines {
+		if offsetPattern.MatchString(line) {
+			dump = true
+			break
+		}
+	}
+
+	var stream strings.Builder
+	for _, line := range lines {
+		if !dump {
+			stream.WriteString(hexCleaner.Replace(line))
+			continue
+		}
+
+		offset := offsetPattern.FindStringSubmatch(line)
+		if offset == nil {
+			continue
+		}
+
+		// Every line must start where the previous one ended, hexdump -C replaces the repeated lines with "*"
+		offsetValue, _ := strconv.ParseUint(offset[1], 16, 64)
+		if offsetValue != uint64(stream.Len()/2) {
+			return nil, fmt.Errorf("the line at offset %s doesn't follow the %d bytes before it, use hexdump -v if lines are repeated", offset[1], stream.Len()/2)
+		}
+
+		stream.WriteString(dumpLineHex(line[len(offset[0]):]))
+	}
+
+	if stream.Len() == 0 {
+		return nil, errors.New("no bytes found")
+	}
+	if stream.Len()%2 != 0 {
+		return nil, errors.New("odd number of hex digits")
+	}
+
+	data, err := hex.DecodeString(stream.String())
+	if err != nil {
+		return nil, errors.New("invalid hex digits, use a hex stream or a tcpdump -X dump")
+	}
+
+	return data, nil
+}
+
+// dumpLineHex returns the hex digits of a line of a dump after its offset. The ASCII column is between pipes in
+// hexdump -C, otherwise it starts after 16 bytes or after a gap of at least 3 spaces in the shorter lines
+func dumpLineHex(line string) string {
+	line = strings.TrimRight(line, "\r")
+	if index := strings.Index(line, "|"); index >= 0 {
+		line = line[:index]
+	}
+
+	var digits strings.Builder
+	count := 0
+	for count < dumpLineBytes {
+		token := strings.TrimLeft(line, " \t")
+		if token == "" || (count > 0 && len(line)-len(token) >= 3) {
+			break
+		}
+
+		// Bytes can be in groups, like the 2 bytes ones of tcpdump -X
+		end := strings.IndexAny(token, " \t")
+		if end < 0 {
+			end = len(token)
+		}
+		if _, err := hex.DecodeString(token[:end]); err != nil || count+end/2 > dumpLineBytes {
+			break
+		}
+
+		digits.WriteString(token[:end])
+		count += end / 2
+		line = token[end:]
+	}
+
+	return digits.String()
+}
@@ -0,0 +1,418 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package packet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Well known ports of TCP and UDP
+var services = map[uint16]string{
+	20:    "FTP data",
+	21:    "FTP",
+	22:    "SSH",
+	23:    "Telnet",
+	25:    "SMTP",
+	53:    "DNS",
+	67:    "DHCP server",
+	68:    "DHCP client",
+	69:    "TFTP",
+	80:    "HTTP",
+	110:   "POP3",
+	123:   "NTP",
+	143:   "IMAP",
+	161:   "SNMP",
+	162:   "SNMP trap",
+	179:   "BGP",
+	389:   "LDAP",
+	443:   "HTTPS",
+	445:   "SMB",
+	500:   "IKE",
+	514:   "Syslog",
+	546:   "DHCPv6 client",
+	547:   "DHCPv6 server",
+	587:   "SMTP submission",
+	636:   "LDAPS",
+	853:   "DNS over TLS",
+	993:   "IMAPS",
+	995:   "POP3S",
+	1812:  "RADIUS",
+	3389:  "RDP",
+	4500:  "IPsec NAT-T",
+	4789:  "VXLAN",
+	5060:  "SIP",
+	6081:  "Geneve",
+	51820: "WireGuard",
+}
+
+// Flags of the TCP header, from the most significant
+var tcpFlags = []struct {
+	bit  uint16
+	name string
+}{{0x100, "NS"}, {0x80, "CWR"}, {0x40, "ECE"}, {0x20, "URG"}, {0x10, "ACK"}, {0x08, "PSH"}, {0x04, "RST"}, {0x02, "SYN"}, {0x01, "FIN"}}
+
+// Types of the ICMP messages, with the names of their codes
+var icmpTypes = map[uint8]struct {
+	name  string
+	codes map[uint8]string
+}{
+	0: {"Echo Reply", nil},
+	3: {"Destination Unreachable", map[uint8]string{
+		0: "network unreachable", 1: "host unreachable", 2: "protocol unreachable", 3: "port unreachable",
+		4: "fragmentation needed and DF set", 5: "source route failed", 6: "destination network unknown",
+		7: "destination host unknown", 9: "network administratively prohibited", 10: "host administratively prohibited",
+		13: "communication administratively prohibited",
+	}},
+	4:  {"Source Quench", nil},
+	5:  {"Redirect", map[uint8]string{0: "network", 1: "host", 2: "TOS and network", 3: "TOS and host"}},
+	8:  {"Echo Request", nil},
+	9:  {"Router Advertisement", nil},
+	10: {"Router Solicitation", nil},
+	11: {"Time Exceeded", map[uint8]string{0: "TTL exceeded in transit", 1: "fragment reassembly time exceeded"}},
+	12: {"Parameter Problem", map[uint8]string{0: "pointer indicates the error", 1: "missing a required option", 2: "bad length"}},
+	13: {"Timestamp", nil},
+	14: {"Timestamp Reply", nil},
+}
+
+// Types of the ICMPv6 messages, with the names of their codes
+var icmpv6Types = map[uint8]struct {
+	name  string
+	codes map[uint8]string
+}{
+	1: {"Destination Unreachable", map[uint8]string{
+		0: "no route to destination", 1: "administratively prohibited", 2: "beyond scope of source address",
+		3: "address unreachable", 4: "port unreachable", 5: "source address failed policy", 6: "reject route",
+	}},
+	2:   {"Packet Too Big", nil},
+	3:   {"Time Exceeded", map[uint8]string{0: "hop limit exceeded in transit", 1: "fragment reassembly time exceeded"}},
+	4:   {"Parameter Problem", map[uint8]string{0: "erroneous header field", 1: "unrecognized next header", 2: "unrecognized IPv6 option"}},
+	128: {"Echo Request", nil},
+	129: {"Echo Reply", nil},
+	133: {"Router Solicitation", nil},
+	134: {"Router Advertisement", nil},
+	135: {"Neighbor Solicitation", nil},
+	136: {"Neighbor Advertisement", nil},
+	137: {"Redirect", nil},
+	143: {"MLDv2 Report", nil},
+}
+
+// upperLayer is the payload of an IP packet, with what's needed to verify its checksum
+type upperLayer struct {
+	protocol   uint8
+	ipv6       bool
+	pseudo     func(length int) []byte // Pseudo header of the checksums of TCP, UDP and ICMPv6
+	length     int                     // Length declared by the IP header, the capture can be shorter
+	incomplete string                  // Why the checksum can't be verified, empty if it can
+}
+
+// checksum returns the Internet checksum (RFC 1071) of data
+func checksum(data []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(data); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(data[i:]))
+	}
+	if len(data)%2 == 1 {
+		sum += uint32(data[len(data)-1]) << 8
+	}
+	for sum > 0xffff {
+		sum = sum>>16 + sum&0xffff
+	}
+
+	return ^uint16(sum)
+}
+
+// checksumField adds a checksum with the result of its verification, reason tells why it wasn't verified
+func checksumField(layer *Field, name string, declared uint16, computed uint16, reason string) {
+	switch {
+	case reason != "":
+		layer.add(name, "0x%04x (not verified, %s)", declared, reason)
+	case declared == computed:
+		layer.add(name, "0x%04x (correct)", declared)
+	default:
+		layer.add(name, "0x%04x (WRONG, should be 0x%04x; the captures of the sender can show it with checksum offload)", declared, computed)
+	}
+}
+
+// portName returns a port with the name of its service
+func portName(port uint16) string {
+	if name, found := services[port]; found {
+		return fmt.Sprintf("%d (%s)", port, name)
+	}
+
+	return fmt.Sprintf("%d", port)
+}
+
+// verify adds the checksum at position of a segment, computed with the pseudo header if needed
+func (upper upperLayer) verify(layer *Field, segment []byte, position int, pseudo bool) uint16 {
+	declared := binary.BigEndian.Uint16(segment[position:])
+	if upper.incomplete != "" {
+		checksumField(layer, "Checksum", declared, 0, upper.incomplete)
+		return 0
+	}
+
+	data := append([]byte(nil), segment...)
+	data[position], data[position+1] = 0, 0
+	if pseudo {
+		data = append(upper.pseudo(len(segment)), data...)
+	}
+	computed := checksum(data)
+
+	// A computed UDP checksum of zero is sent as all ones, zero means no checksum
+	if upper.protocol == 17 && computed == 0 {
+		computed = 0xffff
+	}
+	checksumField(layer, "Checksum", declared, computed, "")
+
+	return computed
+}
+
+// decode decodes the payload of an IP packet
+func (upper upperLayer) decode(payload []byte) []*Field {
+	if len(payload) < upper.length && upper.incomplete == "" {
+		upper.incomplete = "the capture is shorter than the packet"
+	}
+
+	switch upper.protocol {
+	case 6:
+		return []*Field{upper.decodeTCP(payload)}
+	case 17:
+		return []*Field{upper.decodeUDP(payload)}
+	case 1:
+		return []*Field{upper.decodeICMP(payload)}
+	case 58:
+		return []*Field{upper.decodeICMPv6(payload)}
+	case 4:
+		return decodeIPv4(payload)
+	case 41:
+		return decodeIPv6(payload)
+	case 59:
+		return nil
+	}
+
+	name, found := protocols[upper.protocol]
+	if !found {
+		name = fmt.Sprintf("Protocol %d", upper.protocol)
+	}
+	layer := &Field{Name: name + " payload"}
+	layer.add("Length", "%d bytes, not decoded", len(payload))
+	return []*Field{layer}
+}
+
+// decodeTCP decodes a TCP header (RFC 9293) with its options
+func (upper upperLayer) decodeTCP(segment []byte) *Field {
+	layer := &Field{Name: "TCP"}
+	if len(segment) < 20 {
+		return truncated(layer, len(segment), 20)[0]
+	}
+
+	headerLength := int(segment[12]>>4) * 4
+	flags := binary.BigEndian.Uint16(segment[12:]) & 0x1ff
+	flagNames := make([]string, 0, 3)
+	for _, flag := range tcpFlags {
+		if flags&flag.bit != 0 {
+			flagNames = append(flagNames, flag.name)
+		}
+	}
+
+	layer.add("Source port", "%s", portName(binary.BigEndian.Uint16(segment)))
+	layer.add("Destination port", "%s", portName(binary.BigEndian.Uint16(segment[2:])))
+	layer.add("Sequence number", "%d", binary.BigEndian.Uint32(segment[4:]))
+	layer.add("Acknowledgment number", "%d", binary.BigEndian.Uint32(segment[8:]))
+	layer.add("Header length", "%d bytes (%d)", headerLength, headerLength/4)
+	layer.add("Flags", "%s", strings.TrimSuffix(fmt.Sprintf("0x%03x (%s)", flags, strings.Join(flagNames, ", ")), " ()"))
+	layer.add("Window", "%d", binary.BigEndian.Uint16(segment[14:]))
+	upper.verify(layer, segment, 16, true)
+	layer.add("Urgent pointer", "%d", binary.BigEndian.Uint16(segment[18:]))
+
+	if headerLength < 20 || headerLength > len(segment) {
+		layer.add("Options", "invalid header length")
+		return layer
+	}
+	if headerLength > 20 {
+		options := layer.add("Options", "%d bytes", headerLength-20)
+		decodeTCPOptions(options, segment[20:headerLength])
+	}
+
+	layer.add("Payload", "%d bytes", upper.length-headerLength)
+	return layer
+}
+
+// decodeTCPOptions adds the TCP options to their field
+func decodeTCPOptions(field *Field, options []byte) {
+	for i := 0; i < len(options); {
+		kind := options[i]
+		if kind == 0 {
+			field.add("End of options list", "")
+			return
+		}
+		if kind == 1 {
+			field.add("NOP", "")
+			i++
+			continue
+		}
+		if i+1 >= len(options) || options[i+1] < 2 || i+int(options[i+1]) > len(options) {
+			field.add("Invalid option", "kind %d", kind)
+			return
+		}
+
+		length := int(options[i+1])
+		value := options[i+2 : i+length]
+		switch {
+		case kind == 2 && length == 4:
+			field.add("Maximum segment size", "%d", binary.BigEndian.Uint16(value))
+		case kind == 3 && length == 3:
+			field.add("Window scale", "%d (multiply by %d)", value[0], 1<<uint(value[0]))
+		case kind == 4 && length == 2:
+			field.add("SACK permitted", "")
+		case kind == 5 && (length-2)%8 == 0:
+			blocks := make([]string, 0, (length-2)/8)
+			for j := 0; j < len(value); j += 8 {
+				blocks = append(blocks, fmt.Sprintf("%d-%d", binary.BigEndian.Uint32(value[j:]), binary.BigEndian.Uint32(value[j+4:])))
+			}
+			field.add("SACK", "%s", strings.Join(blocks, ", "))
+		case kind == 8 && length == 10:
+			field.add("Timestamps", "value %d, echo reply %d", binary.BigEndian.Uint32(value), binary.BigEndian.Uint32(value[4:]))
+		case kind == 30:
+			field.add("Multipath TCP", "%d bytes", length)
+		case kind == 34:
+			field.add("TCP Fast Open cookie", "%x", value)
+		default:
+			field.add("Option", "kind %d, %d bytes", kind, length)
+		}
+		i += length
+	}
+}
+
+// decodeUDP decodes a UDP header (RFC 768)
+func (upper upperLayer) decodeUDP(datagram []byte) *Field {
+	layer := &Field{Name: "UDP"}
+	if len(datagram) < 8 {
+		return truncated(layer, len(datagram), 8)[0]
+	}
+
+	length := int(binary.BigEndian.Uint16(datagram[4:]))
+	layer.add("Source port", "%s", portName(binary.BigEndian.Uint16(datagram)))
+	layer.add("Destination port", "%s", portName(binary.BigEndian.Uint16(datagram[2:])))
+	lengthField := layer.add("Length", "%d", length)
+	if length != upper.length {
+		lengthField.Value += fmt.Sprintf(" (the IP header says %d)", upper.length)
+	}
+
+	if binary.BigEndian.Uint16(datagram[6:]) == 0 && !upper.ipv6 {
+		layer.add("Checksum", "0x0000 (none, it's optional over IPv4)")
+	} else {
+		upper.verify(layer, datagram, 6, true)
+	}
+
+	layer.add("Payload", "%d bytes", length-8)
+	return layer
+}
+
+// decodeICMP decodes an ICMP message (RFC 792), with the original datagram of the errors
+func (upper upperLayer) decodeICMP(message []byte) *Field {
+	layer := &Field{Name: "ICMP"}
+	if len(message) < 8 {
+		return truncated(layer, len(message), 8)[0]
+	}
+
+	kind, code := message[0], message[1]
+	typeName, codeName := "unknown", ""
+	if known, found := icmpTypes[kind]; found {
+		typeName, codeName = known.name, known.codes[code]
+	}
+	layer.add("Type", "%d (%s)", kind, typeName)
+	layer.add("Code", "%s", strings.TrimSuffix(fmt.Sprintf("%d (%s)", code, codeName), " ()"))
+	upper.verify(layer, message, 2, false)
+
+	switch kind {
+	case 0, 8, 13, 14:
+		layer.add("Identifier", "%d", binary.BigEndian.Uint16(message[4:]))
+		layer.add("Sequence number", "%d", binary.BigEndian.Uint16(message[6:]))
+		layer.add("Data", "%d bytes", len(message)-8)
+	case 3, 4, 5, 11, 12:
+		if kind == 3 && code == 4 {
+			layer.add("Next-hop MTU", "%d", binary.BigEndian.Uint16(message[6:]))
+		}
+		if kind == 5 {
+			layer.add("Gateway", "%s", net.IP(message[4:8]))
+		}
+		if kind == 12 {
+			layer.add("Pointer", "%d", message[4])
+		}
+		if len(message) > 8 {
+			original := layer.add("Original datagram", "%d bytes", len(message)-8)
+			original.Children = decodeIPv4(message[8:])
+		}
+	}
+
+	return layer
+}
+
+// decodeICMPv6 decodes an ICMPv6 message (RFC 4443 and RFC 4861), with the original packet of the errors
+func (upper upperLayer) decodeICMPv6(message []byte) *Field {
+	layer := &Field{Name: "ICMPv6"}
+	if len(message) < 8 {
+		return truncated(layer, len(message), 8)[0]
+	}
+
+	kind, code := message[0], message[1]
+	typeName, codeName := "unknown", ""
+	if known, found := icmpv6Types[kind]; found {
+		typeName, codeName = known.name, known.codes[code]
+	}
+	layer.add("Type", "%d (%s)", kind, typeName)
+	layer.add("Code", "%s", strings.TrimSuffix(fmt.Sprintf("%d (%s)", code, codeName), " ()"))
+	upper.verify(layer, message, 2, true)
+
+	switch kind {
+	case 128, 129:
+		layer.add("Identifier", "%d", binary.BigEndian.Uint16(message[4:]))
+		layer.add("Sequence number", "%d", binary.BigEndian.Uint16(message[6:]))
+		layer.add("Data", "%d bytes", len(message)-8)
+	case 135, 136:
+		if kind == 136 {
+			flags := make([]string, 0, 3)
+			for _, flag := range []struct {
+				bit  byte
+				name string
+			}{{0x80, "router"}, {0x40, "solicited"}, {0x20, "override"}} {
+				if message[4]&flag.bit != 0 {
+					flags = append(flags, flag.name)
+				}
+			}
+			layer.add("Flags", "%s", strings.TrimSuffix(fmt.Sprintf("0x%02x (%s)", message[4], strings.Join(flags, ", ")), " ()"))
+		}
+		if len(message) >= 24 {
+			layer.add("Target", "%s", net.IP(message[8:24]))
+		}
+	case 1, 2, 3, 4:
+		if kind == 2 {
+			layer.add("MTU", "%d", binary.BigEndian.Uint32(message[4:]))
+		}
+		if kind == 4 {
+			layer.add("Pointer", "%d", binary.BigEndian.Uint32(message[4:]))
+		}
+		if len(message) > 8 {
+			original := layer.add("Original packet", "%d bytes", len(message)-8)
+			original.Children = decodeIPv6(message[8:])
+		}
+	}
+
+	return layer
+}
@@ -0,0 +1,99 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package prefixsets
+
+import (
+	"net"
+	"sort"
+)
+
+// index finds the entries of a set containing an address. Every prefix length has a map from the network to the
+// entries, so a lookup costs a map access for each length used by the set, whatever the size of the set.
+// IPv4 and IPv6 have separate tables, so an IPv6 prefix like ::/8 never contains an IPv4 address
+type index struct {
+	ipv4 *family
+	ipv6 *family
+}
+
+// family contains the prefixes of an address family of an index
+type family struct {
+	bits     int                      // Bits of the addresses of the family
+	lengths  []int                    // Lengths of the prefixes, from the shortest
+	networks map[int]map[string][]int // Positions of the entries in the set, by length and network
+}
+
+// parsePrefix returns the network and the length of a prefix, with the IPv4 networks in their 4 bytes form
+func parsePrefix(prefix string) (net.IP, int, bool) {
+	_, ipNet, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return nil, 0, false
+	}
+
+	ones, _ := ipNet.Mask.Size()
+	return ipNet.IP, ones, true
+}
+
+// newFamily creates the empty table of an address family
+func newFamily(bits int) *family {
+	return &family{bits: bits, lengths: make([]int, 0), networks: make(map[int]map[string][]int)}
+}
+
+// add indexes the entry at a position of the set
+func (table *family) add(ip net.IP, length int, position int) {
+	if table.networks[length] == nil {
+		table.networks[length] = make(map[string][]int)
+		table.lengths = append(table.lengths, length)
+	}
+	table.networks[length][string(ip)] = append(table.networks[length][string(ip)], position)
+}
+
+// newIndex indexes the entries of a set
+func newIndex(set *Set) *index {
+	idx := &index{ipv4: newFamily(net.IPv4len * 8), ipv6: newFamily(net.IPv6len * 8)}
+
+	for i, entry := range set.Entries {
+		ip, length, ok := parsePrefix(entry.Prefix)
+		if !ok {
+			continue
+		}
+
+		if len(ip) == net.IPv4len {
+			idx.ipv4.add(ip, length, i)
+		} else {
+			idx.ipv6.add(ip, length, i)
+		}
+	}
+	sort.Ints(idx.ipv4.lengths)
+	sort.Ints(idx.ipv6.lengths)
+
+	return idx
+}
+
+// lookup returns the positions of the entries containing an address, from the least specific
+func (idx *index) lookup(ip net.IP) []int {
+	table, address := idx.ipv6, ip.To16()
+	if ip4 := ip.To4(); ip4 != nil {
+		table, address = idx.ipv4, ip4
+	}
+
+	positions := make([]int, 0)
+	for _, length := range table.lengths {
+		network := address.Mask(net.CIDRMask(length, table.bits))
+		positions = append(positions, table.networks[length][string(network)]...)
+	}
+
+	return positions
+}
@@ -0,0 +1,102 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package prefixsets
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// Entry is a prefix of a set, with the service or region it's published for
+type Entry struct {
+	Prefix string `json:"prefix"`
+	Tag    string `json:"tag,omitempty"`
+}
+
+// Set is a named list of prefixes, like the published ranges of a cloud provider or a blocklist
+type Set struct {
+	Name    string    `json:"name"`
+	Format  string    `json:"format"`
+	Source  string    `json:"source"`
+	Updated time.Time `json:"updated"`
+	Entries []Entry   `json:"entries"`
+}
+
+// Sets contains every prefix set with its index
+type Sets struct {
+	Sets     map[string]*Set `json:"sets"`
+	indexes  map[string]*index
+	filename string
+	mutex    *sync.RWMutex
+}
+
+// Create a new prefix sets instance from filename and return Sets pointer. A missing file means no sets.
+func NewSets(filename string) (*Sets, error) {
+	// Instantiate a new sets struct
+	sets := new(Sets)
+	sets.Sets = make(map[string]*Set)
+	sets.indexes = make(map[string]*index)
+	sets.filename = filename
+	sets.mutex = &sync.RWMutex{}
+
+	// Read file to a byte slice
+	setsFile, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return sets, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode json file to sets struct
+	err = json.Unmarshal(setsFile, sets)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, set := range sets.Sets {
+		sets.indexes[name] = newIndex(set)
+	}
+
+	return sets, nil
+}
+
+// Close a previously opened prefix sets instance
+func (sets *Sets) Close() {
+	sets.Sets = nil
+	sets.indexes = nil
+	sets.mutex = nil
+	sets.filename = ""
+}
+
+// Write the sets to file, the caller must hold the mutex.
+// The json is written to a temporary file first, so a failure never leaves a truncated file.
+func (sets *Sets) save() error {
+	jsonSets, err := json.Marshal(sets)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(sets.filename+".tmp", jsonSets, 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(sets.filename+".tmp", sets.filename)
+}
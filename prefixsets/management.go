@@ -0,0 +1,127 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package prefixsets
+
+import (
+	"errors"
+	"net"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// Names of the sets, used in the commands
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,31}$`)
+
+// Match is an entry of a set containing an address
+type Match struct {
+	Set    string
+	Prefix string
+	Tag    string
+}
+
+// Replace creates a set or replaces the entries of an existing one, the lookups see the new entries at once
+func (sets *Sets) Replace(name string, format string, source string, entries []Entry) error {
+	if !namePattern.MatchString(name) {
+		return errors.New("the name of a set must be lowercase letters, digits, dots, dashes and underscores, at most 32")
+	}
+	if len(entries) == 0 {
+		return errors.New("the set has no prefixes")
+	}
+
+	// The index is built before taking the lock, so the lookups aren't blocked meanwhile
+	set := &Set{Name: name, Format: format, Source: source, Updated: time.Now(), Entries: entries}
+	setIndex := newIndex(set)
+
+	sets.mutex.Lock()
+	defer sets.mutex.Unlock()
+
+	previous, existed := sets.Sets[name]
+	sets.Sets[name] = set
+	if err := sets.save(); err != nil {
+		// Restore the previous set because the file wasn't updated
+		if existed {
+			sets.Sets[name] = previous
+		} else {
+			delete(sets.Sets, name)
+		}
+		return err
+	}
+	sets.indexes[name] = setIndex
+
+	return nil
+}
+
+// Remove deletes a set
+func (sets *Sets) Remove(name string) error {
+	sets.mutex.Lock()
+	defer sets.mutex.Unlock()
+
+	previous, existed := sets.Sets[name]
+	if !existed {
+		return errors.New("there is no set named " + name)
+	}
+
+	delete(sets.Sets, name)
+	if err := sets.save(); err != nil {
+		// Restore the set because the file wasn't updated
+		sets.Sets[name] = previous
+		return err
+	}
+	delete(sets.indexes, name)
+
+	return nil
+}
+
+// List returns the sets sorted by name. The sets are never modified, only replaced, so they can be read freely
+func (sets *Sets) List() []*Set {
+	sets.mutex.RLock()
+	defer sets.mutex.RUnlock()
+
+	list := make([]*Set, 0, len(sets.Sets))
+	for _, set := range sets.Sets {
+		list = append(list, set)
+	}
+	sort.Slice(list, func(a, b int) bool {
+		return list[a].Name < list[b].Name
+	})
+
+	return list
+}
+
+// Lookup returns the entries of every set containing an address, sorted by set and from the most specific prefix
+func (sets *Sets) Lookup(ip net.IP) []Match {
+	sets.mutex.RLock()
+	defer sets.mutex.RUnlock()
+
+	names := make([]string, 0, len(sets.Sets))
+	for name := range sets.Sets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	matches := make([]Match, 0)
+	for _, name := range names {
+		set := sets.Sets[name]
+		positions := sets.indexes[name].lookup(ip)
+		for i := len(positions) - 1; i >= 0; i-- {
+			entry := set.Entries[positions[i]]
+			matches = append(matches, Match{set.Name, entry.Prefix, entry.Tag})
+		}
+	}
+
+	return matches
+}
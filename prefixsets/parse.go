@@ -0,0 +1,202 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package prefixsets
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-Telegram-NetworkCalculator-bot/network"
+	"net"
+	"strings"
+)
+
+// publishedRanges contains the fields of the JSON ranges published by the cloud providers
+type publishedRanges struct {
+	// AWS ip-ranges.json and Google cloud.json or goog.json
+	Prefixes []struct {
+		IPPrefix   string `json:"ip_prefix"`
+		IPv4Prefix string `json:"ipv4Prefix"`
+		IPv6Prefix string `json:"ipv6Prefix"`
+		Service    string `json:"service"`
+		Region     string `json:"region"`
+		Scope      string `json:"scope"`
+	} `json:"prefixes"`
+	IPv6Prefixes []struct {
+		IPv6Prefix string `json:"ipv6_prefix"`
+		Service    string `json:"service"`
+		Region     string `json:"region"`
+	} `json:"ipv6_prefixes"`
+
+	// Azure ServiceTags_Public.json
+	Values []struct {
+		Name       string `json:"name"`
+		Properties struct {
+			AddressPrefixes []string `json:"addressPrefixes"`
+		} `json:"properties"`
+	} `json:"values"`
+
+	// Cloudflare api.cloudflare.com/client/v4/ips
+	Result struct {
+		IPv4Cidrs []string `json:"ipv4_cidrs"`
+		IPv6Cidrs []string `json:"ipv6_cidrs"`
+	} `json:"result"`
+}
+
+// parseEntry returns the entries of an address, a prefix or a range "first-last", masked to their network
+func parseEntry(token string, tag string) ([]Entry, bool) {
+	var prefixes []*net.IPNet
+
+	if ip := net.ParseIP(token); ip != nil {
+		length := net.IPv6len * 8
+		if ip.To4() != nil {
+			ip, length = ip.To4(), net.IPv4len*8
+		}
+		prefixes = []*net.IPNet{{IP: ip, Mask: net.CIDRMask(length, length)}}
+	} else if _, ipNet, err := net.ParseCIDR(token); err == nil {
+		prefixes = []*net.IPNet{ipNet}
+	} else if parts := strings.Split(token, "-"); len(parts) == 2 {
+		first, last := net.ParseIP(parts[0]), net.ParseIP(parts[1])
+		if first == nil || last == nil || (first.To4() == nil) != (last.To4() == nil) || network.CompareIP(first, last) > 0 {
+			return nil, false
+		}
+		prefixes = network.RangeToIPNets(first, last)
+	} else {
+		return nil, false
+	}
+
+	entries := make([]Entry, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		entries = append(entries, Entry{prefix.String(), tag})
+	}
+
+	return entries, true
+}
+
+// joinTags returns the non empty tags separated by a space
+func joinTags(tags ...string) string {
+	nonEmpty := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag != "" {
+			nonEmpty = append(nonEmpty, tag)
+		}
+	}
+
+	return strings.Join(nonEmpty, " ")
+}
+
+// parseJSON reads the ranges published as JSON by AWS, Google, Azure and Cloudflare
+func parseJSON(content []byte) (string, []Entry, error) {
+	var ranges publishedRanges
+	if err := json.Unmarshal(content, &ranges); err != nil {
+		return "", nil, err
+	}
+
+	format := ""
+	tokens := make([]Entry, 0)
+	for _, prefix := range ranges.Prefixes {
+		switch {
+		case prefix.IPPrefix != "":
+			format = "AWS"
+			tokens = append(tokens, Entry{prefix.IPPrefix, joinTags(prefix.Service, prefix.Region)})
+		case prefix.IPv4Prefix != "" || prefix.IPv6Prefix != "":
+			format = "Google"
+			tokens = append(tokens, Entry{prefix.IPv4Prefix + prefix.IPv6Prefix, joinTags(prefix.Service, prefix.Scope)})
+		}
+	}
+	for _, prefix := range ranges.IPv6Prefixes {
+		tokens = append(tokens, Entry{prefix.IPv6Prefix, joinTags(prefix.Service, prefix.Region)})
+	}
+	for _, value := range ranges.Values {
+		format = "Azure"
+		for _, prefix := range value.Properties.AddressPrefixes {
+			tokens = append(tokens, Entry{prefix, value.Name})
+		}
+	}
+	for _, prefix := range append(ranges.Result.IPv4Cidrs, ranges.Result.IPv6Cidrs...) {
+		format = "Cloudflare"
+		tokens = append(tokens, Entry{prefix, ""})
+	}
+
+	entries := make([]Entry, 0, len(tokens))
+	for _, token := range tokens {
+		parsed, ok := parseEntry(token.Prefix, token.Tag)
+		if !ok {
+			return "", nil, fmt.Errorf("invalid prefix %s", token.Prefix)
+		}
+		entries = append(entries, parsed...)
+	}
+
+	if format == "" {
+		return "", nil, errors.New("unknown JSON format, only the AWS, Google, Azure and Cloudflare ones are supported")
+	}
+	return format + " JSON", entries, nil
+}
+
+// parseText reads a list with an address, prefix or range on every line, optionally followed by its tag, like the
+// Team Cymru bogons or a blocklist. Comments start with # or ;, the lines without digits are headers and are skipped
+func parseText(content []byte) (string, []Entry, error) {
+	entries := make([]Entry, 0)
+
+	for i, line := range strings.Split(string(content), "\n") {
+		if index := strings.IndexAny(line, "#;"); index >= 0 {
+			line = line[:index]
+		}
+		line = strings.TrimSpace(line)
+		if !strings.ContainsAny(line, "0123456789") {
+			continue
+		}
+
+		token, tag := line, ""
+		if index := strings.IndexAny(line, " \t,"); index >= 0 {
+			token, tag = line[:index], strings.Trim(line[index:], " \t,\"")
+		}
+
+		parsed, ok := parseEntry(strings.Trim(token, "\""), tag)
+		if !ok {
+			return "", nil, fmt.Errorf("invalid prefix %s at line %d", token, i+1)
+		}
+		entries = append(entries, parsed...)
+	}
+
+	return "text", entries, nil
+}
+
+// Parse reads the prefixes of a set from the JSON ranges of a cloud provider or from a text list, returning the
+// detected format. The repeated entries are removed
+func Parse(content []byte) (string, []Entry, error) {
+	parse := parseText
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("{")) {
+		parse = parseJSON
+	}
+
+	format, entries, err := parse(content)
+	if err != nil {
+		return "", nil, err
+	}
+
+	seen := make(map[Entry]bool)
+	unique := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		if !seen[entry] {
+			seen[entry] = true
+			unique = append(unique, entry)
+		}
+	}
+
+	return format, unique, nil
+}
@@ -0,0 +1,169 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package rdap
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Maximum quantity of cached answers, the expired ones are removed first when it's reached
+const maxCacheEntries = 1000
+
+// Queries that aren't addresses: autonomous system numbers and domain names
+var (
+	asnPattern    = regexp.MustCompile(`^(?i)as(\d{1,10})$`)
+	domainPattern = regexp.MustCompile(`^(?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z0-9-]{2,63}$`)
+)
+
+// ErrNotFound is returned when the registry has no object for the query
+var ErrNotFound = errors.New("the registry has no object for this query")
+
+// Result is the registration of an address block, an autonomous system or a domain
+type Result struct {
+	Source     string // URL of the RDAP answer, or the WHOIS server
+	Kind       string // "ip network", "autnum" or "domain"
+	Handle     string
+	Name       string
+	Holder     string
+	Block      string // Range of addresses or of autonomous system numbers, or the domain name
+	Type       string // Allocation type, like "ALLOCATED PA"
+	Country    string
+	Status     []string
+	Abuse      []string // Abuse contacts, like "Abuse Team <abuse@example.com>"
+	Registered string
+	Updated    string
+	Expires    string
+	Whois      string // Answer of the WHOIS server, when RDAP wasn't available
+}
+
+// cacheEntry is an answer kept until its expiry
+type cacheEntry struct {
+	result Result
+	err    error
+	expiry time.Time
+}
+
+// Client queries an RDAP server, falling back to a WHOIS server, and caches the answers
+type Client struct {
+	server      string
+	whoisServer string
+	timeout     time.Duration
+	cacheTime   time.Duration
+	http        *http.Client
+	cache       map[string]cacheEntry
+	mutex       *sync.Mutex
+}
+
+// Create a new RDAP client from the base URL of an RDAP server (like https://rdap.org/) and the "host:port" of a WHOIS
+// server used when RDAP fails ("" to disable the fallback). Every query is cancelled after timeout.
+func NewClient(server string, whoisServer string, timeout time.Duration, cacheTime time.Duration) *Client {
+	return &Client{
+		server:      strings.TrimRight(server, "/") + "/",
+		whoisServer: whoisServer,
+		timeout:     timeout,
+		cacheTime:   cacheTime,
+		http:        &http.Client{Timeout: timeout},
+		cache:       make(map[string]cacheEntry),
+		mutex:       &sync.Mutex{},
+	}
+}
+
+// Path returns the RDAP path of a query: an address or prefix, an autonomous system ("AS123" or "123") or a domain
+func Path(query string) (string, error) {
+	query = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(query), "."))
+
+	if ip := net.ParseIP(query); ip != nil {
+		return "ip/" + ip.String(), nil
+	}
+	if _, ipNet, err := net.ParseCIDR(query); err == nil {
+		return "ip/" + ipNet.String(), nil
+	}
+
+	if _, err := strconv.ParseUint(query, 10, 32); err == nil {
+		query = "as" + query
+	}
+	if match := asnPattern.FindStringSubmatch(query); match != nil {
+		if _, err := strconv.ParseUint(match[1], 10, 32); err == nil {
+			return "autnum/" + match[1], nil
+		}
+	}
+
+	if domainPattern.MatchString(query) {
+		return "domain/" + query, nil
+	}
+
+	return "", errors.New("the query must be an address, a prefix, an autonomous system or a domain")
+}
+
+// Query returns the registration of an address, autonomous system or domain, from the cache if it's recent
+func (client *Client) Query(query string) (Result, error) {
+	path, err := Path(query)
+	if err != nil {
+		return Result{}, err
+	}
+
+	client.mutex.Lock()
+	entry, found := client.cache[path]
+	client.mutex.Unlock()
+	if found && time.Now().Before(entry.expiry) {
+		return entry.result, entry.err
+	}
+
+	result, err := client.queryRdap(path)
+	if err != nil && err != ErrNotFound && client.whoisServer != "" {
+		// The registry has no RDAP service or it isn't reachable, try the WHOIS one
+		result, err = client.queryWhois(path)
+	}
+
+	// Only the answers are cached, not the failures, so a temporary error is retried at the next query
+	if err == nil || err == ErrNotFound {
+		client.store(path, cacheEntry{result, err, time.Now().Add(client.cacheTime)})
+	}
+
+	return result, err
+}
+
+// store adds an answer to the cache, making room if it's full
+func (client *Client) store(path string, entry cacheEntry) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	if len(client.cache) >= maxCacheEntries {
+		now := time.Now()
+		for key, cached := range client.cache {
+			if now.After(cached.expiry) {
+				delete(client.cache, key)
+			}
+		}
+	}
+
+	// Still full: evict any entry, the map order is random
+	for key := range client.cache {
+		if len(client.cache) < maxCacheEntries {
+			break
+		}
+		delete(client.cache, key)
+	}
+
+	client.cache[path] = entry
+}
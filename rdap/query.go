@@ -0,0 +1,258 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package rdap
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Maximum size in bytes of the answers read from the servers
+const maxAnswerSize = 1 << 20
+
+// rdapEntity is a contact of an RDAP object (RFC 9083), with its jCard
+type rdapEntity struct {
+	Handle     string        `json:"handle"`
+	Roles      []string      `json:"roles"`
+	VcardArray []interface{} `json:"vcardArray"`
+	Entities   []rdapEntity  `json:"entities"`
+}
+
+// rdapObject contains the fields of the ip network, autnum and domain RDAP objects
+type rdapObject struct {
+	ObjectClassName string `json:"objectClassName"`
+	Handle          string `json:"handle"`
+	Name            string `json:"name"`
+	Type            string `json:"type"`
+	Country         string `json:"country"`
+	StartAddress    string `json:"startAddress"`
+	EndAddress      string `json:"endAddress"`
+	Cidrs           []struct {
+		V4Prefix string `json:"v4prefix"`
+		V6Prefix string `json:"v6prefix"`
+		Length   int    `json:"length"`
+	} `json:"cidr0_cidrs"`
+	StartAutnum uint64   `json:"startAutnum"`
+	EndAutnum   uint64   `json:"endAutnum"`
+	LdhName     string   `json:"ldhName"`
+	Status      []string `json:"status"`
+	Events      []struct {
+		Action string `json:"eventAction"`
+		Date   string `json:"eventDate"`
+	} `json:"events"`
+	Entities []rdapEntity `json:"entities"`
+}
+
+// vcardValues returns the values of a property of a jCard, like "fn" or "email"
+func (entity rdapEntity) vcardValues(property string) []string {
+	values := make([]string, 0)
+	if len(entity.VcardArray) < 2 {
+		return values
+	}
+
+	properties, _ := entity.VcardArray[1].([]interface{})
+	for _, item := range properties {
+		fields, ok := item.([]interface{})
+		if !ok || len(fields) < 4 || fields[0] != property {
+			continue
+		}
+		if value, ok := fields[3].(string); ok && value != "" {
+			values = append(values, strings.TrimPrefix(value, "tel:"))
+		}
+	}
+
+	return values
+}
+
+// name returns the full name of an entity, or its organization or handle
+func (entity rdapEntity) name() string {
+	for _, property := range []string{"fn", "org"} {
+		if values := entity.vcardValues(property); len(values) > 0 {
+			return values[0]
+		}
+	}
+
+	return entity.Handle
+}
+
+// findEntities returns the entities with a role, searching also the contacts of the contacts
+func findEntities(entities []rdapEntity, role string) []rdapEntity {
+	found := make([]rdapEntity, 0)
+	for _, entity := range entities {
+		for _, entityRole := range entity.Roles {
+			if entityRole == role {
+				found = append(found, entity)
+				break
+			}
+		}
+		found = append(found, findEntities(entity.Entities, role)...)
+	}
+
+	return found
+}
+
+// formatDate returns the day of an RDAP date, or the date as it is if it isn't RFC 3339
+func formatDate(date string) string {
+	parsed, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return date
+	}
+
+	return parsed.UTC().Format("2006-01-02")
+}
+
+// queryRdap asks an RDAP path to the server, following the redirects of the bootstrap servers like rdap.org
+func (client *Client) queryRdap(path string) (Result, error) {
+	request, err := http.NewRequest(http.MethodGet, client.server+path, nil)
+	if err != nil {
+		return Result{}, err
+	}
+	request.Header.Set("Accept", "application/rdap+json, application/json")
+
+	response, err := client.http.Do(request)
+	if err != nil {
+		return Result{}, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return Result{}, ErrNotFound
+	}
+	if response.StatusCode != http.StatusOK {
+		return Result{}, fmt.Errorf("the RDAP server answered %s", response.Status)
+	}
+
+	var object rdapObject
+	if err = json.NewDecoder(io.LimitReader(response.Body, maxAnswerSize)).Decode(&object); err != nil {
+		return Result{}, fmt.Errorf("invalid RDAP answer: %v", err)
+	}
+
+	result := Result{
+		Source:  response.Request.URL.String(),
+		Kind:    object.ObjectClassName,
+		Handle:  object.Handle,
+		Name:    object.Name,
+		Type:    object.Type,
+		Country: object.Country,
+		Status:  object.Status,
+		Abuse:   make([]string, 0),
+	}
+
+	switch object.ObjectClassName {
+	case "ip network":
+		result.Block = object.StartAddress + " - " + object.EndAddress
+		cidrs := make([]string, 0, len(object.Cidrs))
+		for _, cidr := range object.Cidrs {
+			cidrs = append(cidrs, cidr.V4Prefix+cidr.V6Prefix+"/"+strconv.Itoa(cidr.Length))
+		}
+		if len(cidrs) > 0 {
+			result.Block += " (" + strings.Join(cidrs, ", ") + ")"
+		}
+	case "autnum":
+		result.Block = fmt.Sprintf("AS%d", object.StartAutnum)
+		if object.EndAutnum != object.StartAutnum {
+			result.Block += fmt.Sprintf(" - AS%d", object.EndAutnum)
+		}
+	case "domain":
+		result.Block = strings.ToLower(object.LdhName)
+	}
+
+	if registrants := findEntities(object.Entities, "registrant"); len(registrants) > 0 {
+		result.Holder = registrants[0].name()
+	}
+
+	for _, abuse := range findEntities(object.Entities, "abuse") {
+		contact := abuse.name()
+		for _, email := range abuse.vcardValues("email") {
+			contact += " <" + email + ">"
+		}
+		for _, phone := range abuse.vcardValues("tel") {
+			contact += " " + phone
+		}
+		result.Abuse = append(result.Abuse, contact)
+	}
+
+	for _, event := range object.Events {
+		switch event.Action {
+		case "registration":
+			result.Registered = formatDate(event.Date)
+		case "last changed":
+			result.Updated = formatDate(event.Date)
+		case "expiration":
+			result.Expires = formatDate(event.Date)
+		}
+	}
+
+	return result, nil
+}
+
+// whoisAnswer sends a query to a WHOIS server (RFC 3912) and returns its answer
+func (client *Client) whoisAnswer(server string, query string) (string, error) {
+	connection, err := net.DialTimeout("tcp", server, client.timeout)
+	if err != nil {
+		return "", err
+	}
+	defer connection.Close()
+
+	if err = connection.SetDeadline(time.Now().Add(client.timeout)); err != nil {
+		return "", err
+	}
+	if _, err = connection.Write([]byte(query + "\r\n")); err != nil {
+		return "", err
+	}
+
+	answer, err := ioutil.ReadAll(io.LimitReader(connection, maxAnswerSize))
+	return string(answer), err
+}
+
+// queryWhois asks an RDAP path to the WHOIS server, following a single referral like the ones of whois.iana.org
+func (client *Client) queryWhois(path string) (Result, error) {
+	parts := strings.SplitN(path, "/", 2)
+	kind, query := map[string]string{"ip": "ip network", "autnum": "autnum", "domain": "domain"}[parts[0]], parts[1]
+	if kind == "autnum" {
+		query = "AS" + query
+	}
+
+	server := client.whoisServer
+	answer, err := client.whoisAnswer(server, query)
+	if err != nil {
+		return Result{}, fmt.Errorf("WHOIS query to %s failed: %v", server, err)
+	}
+
+	for _, line := range strings.Split(answer, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && (strings.EqualFold(fields[0], "refer:") || strings.EqualFold(fields[0], "whois:")) {
+			server = net.JoinHostPort(fields[1], "43")
+			if answer, err = client.whoisAnswer(server, query); err != nil {
+				return Result{}, fmt.Errorf("WHOIS query to %s failed: %v", server, err)
+			}
+			break
+		}
+	}
+
+	if strings.TrimSpace(answer) == "" {
+		return Result{}, ErrNotFound
+	}
+
+	return Result{Source: "whois://" + server, Kind: kind, Whois: answer}, nil
+}
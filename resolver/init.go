@@ -0,0 +1,173 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package resolver
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Response is the answer of the resolver to a query
+type Response struct {
+	Server    string
+	Rcode     string   // Response code, like NOERROR or NXDOMAIN
+	Flags     []string // Header flags, like "qr", "aa", "rd" and "ra"
+	Answers   []Record
+	Authority []Record
+	Duration  time.Duration
+}
+
+// Client sends the queries to a recursive resolver
+type Client struct {
+	server  string
+	timeout time.Duration
+}
+
+// Create a new DNS client of the resolver at server ("host:port"), every query is cancelled after timeout
+func NewClient(server string, timeout time.Duration) *Client {
+	return &Client{server: server, timeout: timeout}
+}
+
+// ReverseName returns the name of the PTR record of an address, in in-addr.arpa or ip6.arpa
+func ReverseName(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa.", ip4[3], ip4[2], ip4[1], ip4[0])
+	}
+
+	nibbles := make([]string, 0, net.IPv6len*2)
+	ip16 := ip.To16()
+	for i := net.IPv6len - 1; i >= 0; i-- {
+		nibbles = append(nibbles, strconv.FormatUint(uint64(ip16[i]&0xf), 16), strconv.FormatUint(uint64(ip16[i]>>4), 16))
+	}
+
+	return strings.Join(nibbles, ".") + ".ip6.arpa."
+}
+
+// exchange sends a query and returns the answer, on UDP or on TCP with the length of the messages first
+func (client *Client) exchange(network string, query []byte) ([]byte, error) {
+	connection, err := net.DialTimeout(network, client.server, client.timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer connection.Close()
+
+	if err = connection.SetDeadline(time.Now().Add(client.timeout)); err != nil {
+		return nil, err
+	}
+
+	if network == "udp" {
+		if _, err = connection.Write(query); err != nil {
+			return nil, err
+		}
+
+		answer := make([]byte, 65535)
+		length, err := connection.Read(answer)
+		return answer[:length], err
+	}
+
+	if _, err = connection.Write(append([]byte{byte(len(query) >> 8), byte(len(query))}, query...)); err != nil {
+		return nil, err
+	}
+
+	length := make([]byte, 2)
+	if _, err = io.ReadFull(connection, length); err != nil {
+		return nil, err
+	}
+	answer := make([]byte, binary.BigEndian.Uint16(length))
+	_, err = io.ReadFull(connection, answer)
+	return answer, err
+}
+
+// Query asks the records of a type for a name, retrying on TCP if the UDP answer is truncated
+func (client *Client) Query(name string, qtype uint16) (*Response, error) {
+	// A random id makes the forged answers harder
+	idBytes := make([]byte, 2)
+	if _, err := rand.Read(idBytes); err != nil {
+		return nil, err
+	}
+	id := binary.BigEndian.Uint16(idBytes)
+
+	query, err := encodeQuery(id, name, qtype)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	answer, err := client.exchange("udp", query)
+	if err == nil && len(answer) >= 4 && answer[2]&0x02 != 0 {
+		answer, err = client.exchange("tcp", query)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := decodeResponse(answer, id)
+	if err != nil {
+		return nil, err
+	}
+	response.Server = client.server
+	response.Duration = time.Since(start)
+
+	return response, nil
+}
+
+// decodeResponse reads the answer to the query with the given id
+func decodeResponse(message []byte, id uint16) (*Response, error) {
+	if len(message) < 12 {
+		return nil, errTruncated
+	}
+	if binary.BigEndian.Uint16(message) != id || message[2]&0x80 == 0 {
+		return nil, errors.New("the resolver sent an answer to another query")
+	}
+
+	flags := binary.BigEndian.Uint16(message[2:])
+	response := &Response{Rcode: rcodeName(int(flags & 0xf)), Flags: make([]string, 0)}
+	for _, flag := range []struct {
+		bit  uint16
+		name string
+	}{{0x8000, "qr"}, {0x0400, "aa"}, {0x0200, "tc"}, {0x0100, "rd"}, {0x0080, "ra"}, {0x0020, "ad"}, {0x0010, "cd"}} {
+		if flags&flag.bit != 0 {
+			response.Flags = append(response.Flags, flag.name)
+		}
+	}
+
+	// Skip the questions, they are the one of the query
+	offset := 12
+	for i := 0; i < int(binary.BigEndian.Uint16(message[4:])); i++ {
+		_, next, err := decodeName(message, offset)
+		if err != nil {
+			return nil, err
+		}
+		offset = next + 4
+	}
+
+	var err error
+	if response.Answers, offset, err = decodeRecords(message, offset, int(binary.BigEndian.Uint16(message[6:]))); err != nil {
+		return nil, err
+	}
+	if response.Authority, _, err = decodeRecords(message, offset, int(binary.BigEndian.Uint16(message[8:]))); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
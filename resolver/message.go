@@ -0,0 +1,272 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package resolver
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Types of the records supported by the queries, by name
+var Types = map[string]uint16{
+	"A":     1,
+	"NS":    2,
+	"CNAME": 5,
+	"SOA":   6,
+	"PTR":   12,
+	"MX":    15,
+	"TXT":   16,
+	"AAAA":  28,
+	"SRV":   33,
+	"CAA":   257,
+}
+
+// Response codes (RFC 1035 and RFC 2136) by value
+var rcodes = []string{"NOERROR", "FORMERR", "SERVFAIL", "NXDOMAIN", "NOTIMP", "REFUSED", "YXDOMAIN", "YXRRSET", "NXRRSET", "NOTAUTH", "NOTZONE"}
+
+// Type of the EDNS0 pseudo record (RFC 6891), it announces that the answers can be bigger than 512 bytes
+const (
+	typeOPT       = 41
+	ednsUDPLength = 1232
+)
+
+// errTruncated is returned when a message ends before its records
+var errTruncated = errors.New("truncated DNS message")
+
+// Record is a resource record of an answer
+type Record struct {
+	Name string
+	Type string
+	TTL  uint32
+	Data string // Presentation format of the data, like "10 mail.example.com."
+	IP   net.IP // Address of the A and AAAA records
+}
+
+// typeName returns the name of a record type, or "TYPEn" for the unknown ones (RFC 3597)
+func typeName(value uint16) string {
+	for name, known := range Types {
+		if known == value {
+			return name
+		}
+	}
+
+	return "TYPE" + strconv.Itoa(int(value))
+}
+
+// rcodeName returns the name of a response code
+func rcodeName(value int) string {
+	if value < len(rcodes) {
+		return rcodes[value]
+	}
+
+	return "RCODE" + strconv.Itoa(value)
+}
+
+// encodeName writes a domain name as labels
+func encodeName(name string) ([]byte, error) {
+	name = strings.TrimSuffix(name, ".")
+	encoded := make([]byte, 0, len(name)+2)
+
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			if len(label) == 0 || len(label) > 63 {
+				return nil, fmt.Errorf("invalid label in %s", name)
+			}
+			encoded = append(encoded, byte(len(label)))
+			encoded = append(encoded, label...)
+		}
+	}
+	encoded = append(encoded, 0)
+
+	if len(encoded) > 255 {
+		return nil, fmt.Errorf("%s is longer than 255 bytes", name)
+	}
+	return encoded, nil
+}
+
+// encodeQuery returns a recursive query with an EDNS0 record
+func encodeQuery(id uint16, name string, qtype uint16) ([]byte, error) {
+	encodedName, err := encodeName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Header: id, flags with recursion desired, one question and one additional record
+	message := make([]byte, 12, 12+len(encodedName)+4+11)
+	binary.BigEndian.PutUint16(message[0:], id)
+	binary.BigEndian.PutUint16(message[2:], 0x0100)
+	binary.BigEndian.PutUint16(message[4:], 1)
+	binary.BigEndian.PutUint16(message[10:], 1)
+
+	message = append(message, encodedName...)
+	message = append(message, byte(qtype>>8), byte(qtype), 0, 1)
+
+	// OPT record: root name, type, UDP payload size as class, no extended flags and no options
+	message = append(message, 0, 0, typeOPT, byte(ednsUDPLength>>8), byte(ednsUDPLength&0xff), 0, 0, 0, 0, 0, 0)
+
+	return message, nil
+}
+
+// decodeName reads a domain name at an offset, following the compression pointers, and returns it with the offset
+// after it
+func decodeName(message []byte, offset int) (string, int, error) {
+	labels := make([]string, 0)
+	next := -1
+
+	for jumps := 0; ; jumps++ {
+		if offset >= len(message) || jumps > 127 {
+			return "", 0, errTruncated
+		}
+
+		length := int(message[offset])
+		switch {
+		case length == 0:
+			if next < 0 {
+				next = offset + 1
+			}
+			return strings.Join(labels, ".") + ".", next, nil
+		case length&0xC0 == 0xC0:
+			// Pointer to a previous name, the name ends after the first pointer
+			if offset+1 >= len(message) {
+				return "", 0, errTruncated
+			}
+			if next < 0 {
+				next = offset + 2
+			}
+			offset = int(binary.BigEndian.Uint16(message[offset:]) & 0x3FFF)
+		default:
+			if offset+1+length > len(message) {
+				return "", 0, errTruncated
+			}
+			labels = append(labels, string(message[offset+1:offset+1+length]))
+			offset += 1 + length
+		}
+	}
+}
+
+// decodeCharacterStrings reads the strings of TXT records, each with its length first
+func decodeCharacterStrings(data []byte) ([]string, error) {
+	values := make([]string, 0)
+	for len(data) > 0 {
+		length := int(data[0])
+		if 1+length > len(data) {
+			return nil, errTruncated
+		}
+		values = append(values, strconv.Quote(string(data[1:1+length])))
+		data = data[1+length:]
+	}
+
+	return values, nil
+}
+
+// decodeData returns the presentation format of the data of a record, starting at offset in message
+func decodeData(message []byte, offset int, length int, qtype uint16) (string, net.IP, error) {
+	data := message[offset : offset+length]
+
+	switch qtype {
+	case Types["A"], Types["AAAA"]:
+		if length != net.IPv4len && length != net.IPv6len {
+			return "", nil, errors.New("invalid address record")
+		}
+		ip := net.IP(append([]byte(nil), data...))
+		return ip.String(), ip, nil
+	case Types["NS"], Types["CNAME"], Types["PTR"]:
+		name, _, err := decodeName(message, offset)
+		return name, nil, err
+	case Types["MX"]:
+		if length < 3 {
+			return "", nil, errTruncated
+		}
+		name, _, err := decodeName(message, offset+2)
+		return strconv.Itoa(int(binary.BigEndian.Uint16(data))) + " " + name, nil, err
+	case Types["SRV"]:
+		if length < 7 {
+			return "", nil, errTruncated
+		}
+		name, _, err := decodeName(message, offset+6)
+		return fmt.Sprintf("%d %d %d %s", binary.BigEndian.Uint16(data), binary.BigEndian.Uint16(data[2:]), binary.BigEndian.Uint16(data[4:]), name), nil, err
+	case Types["TXT"]:
+		values, err := decodeCharacterStrings(data)
+		return strings.Join(values, " "), nil, err
+	case Types["SOA"]:
+		primary, next, err := decodeName(message, offset)
+		if err != nil {
+			return "", nil, err
+		}
+		mailbox, next, err := decodeName(message, next)
+		if err != nil {
+			return "", nil, err
+		}
+		if next+20 > offset+length {
+			return "", nil, errTruncated
+		}
+		numbers := make([]string, 0, 5)
+		for i := 0; i < 5; i++ {
+			numbers = append(numbers, strconv.FormatUint(uint64(binary.BigEndian.Uint32(message[next+i*4:])), 10))
+		}
+		return primary + " " + mailbox + " " + strings.Join(numbers, " "), nil, nil
+	case Types["CAA"]:
+		if length < 2 || 2+int(data[1]) > length {
+			return "", nil, errTruncated
+		}
+		tag := string(data[2 : 2+data[1]])
+		return strconv.Itoa(int(data[0])) + " " + tag + " " + strconv.Quote(string(data[2+data[1]:])), nil, nil
+	}
+
+	// Unknown types are shown in the generic format of RFC 3597
+	return `\# ` + strconv.Itoa(length) + " " + hex.EncodeToString(data), nil, nil
+}
+
+// decodeRecords reads count records starting at an offset, returning them with the offset after them
+func decodeRecords(message []byte, offset int, count int) ([]Record, int, error) {
+	records := make([]Record, 0, count)
+
+	for i := 0; i < count; i++ {
+		name, next, err := decodeName(message, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		if next+10 > len(message) {
+			return nil, 0, errTruncated
+		}
+
+		qtype := binary.BigEndian.Uint16(message[next:])
+		ttl := binary.BigEndian.Uint32(message[next+4:])
+		length := int(binary.BigEndian.Uint16(message[next+8:]))
+		offset = next + 10 + length
+		if offset > len(message) {
+			return nil, 0, errTruncated
+		}
+
+		// The OPT pseudo record isn't a real record
+		if qtype == typeOPT {
+			continue
+		}
+
+		data, ip, err := decodeData(message, next+10, length, qtype)
+		if err != nil {
+			return nil, 0, err
+		}
+		records = append(records, Record{name, typeName(qtype), ttl, data, ip})
+	}
+
+	return records, offset, nil
+}
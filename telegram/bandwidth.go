@@ -0,0 +1,141 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"go-Telegram-NetworkCalculator-bot/network"
+	"strconv"
+	"strings"
+	"unicode"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Usage of /bw, also sent for the invalid commands
+const bandwidthUsage = "Usage:\n/bw time <size> <rate> - transfer time\n/bw rate <size> <time> - link speed for a deadline\n/bw bdp <rate> <rtt> - bandwidth-delay product\n/bw window <rate|window> <rtt> - TCP window needed for a rate, or rate allowed by a window\n\nSizes like 1.5GB, 700MiB or 10Gbit (B are bytes, b bits, Ki/Mi/Gi binary prefixes), rates like 100Mbps, 1Gbit/s or 10MB/s, times like 80ms, 30s, 2h or 1d."
+
+// quantityArgs joins the numbers written apart from their unit, like "100 Mbps", to a single argument
+func quantityArgs(fields []string) []string {
+	args := make([]string, 0, len(fields))
+	for i := 0; i < len(fields); i++ {
+		if _, err := strconv.ParseFloat(fields[i], 64); err == nil && i+1 < len(fields) && unicode.IsLetter(rune(fields[i+1][0])) {
+			args = append(args, fields[i]+fields[i+1])
+			i++
+			continue
+		}
+		args = append(args, fields[i])
+	}
+
+	return args
+}
+
+// bandwidthText returns the result of a /bw calculation
+func bandwidthText(args []string) (string, error) {
+	if len(args) < 3 {
+		return "", errors.New("missing arguments")
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "time":
+		bits, err := network.ParseSize(args[1])
+		if err != nil {
+			return "", err
+		}
+		bps, err := network.ParseRate(args[2])
+		if err != nil {
+			return "", err
+		}
+		seconds, err := network.TransferTime(bits, bps)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("Size: %s\nRate: %s\nTransfer time: %s", network.FormatSize(bits), network.FormatRate(bps), network.FormatSeconds(seconds)), nil
+	case "rate":
+		bits, err := network.ParseSize(args[1])
+		if err != nil {
+			return "", err
+		}
+		seconds, err := network.ParseSeconds(args[2])
+		if err != nil {
+			return "", err
+		}
+		bps, err := network.RequiredRate(bits, seconds)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("Size: %s\nTime: %s\nRequired rate: %s", network.FormatSize(bits), network.FormatSeconds(seconds), network.FormatRate(bps)), nil
+	case "bdp", "window":
+		rtt, err := network.ParseSeconds(args[2])
+		if err != nil {
+			return "", err
+		}
+
+		// A window is a size, so it's told apart from a rate by the missing "/s" or "ps"
+		lower := strings.ToLower(args[1])
+		if strings.ToLower(args[0]) == "window" && !strings.HasSuffix(lower, "/s") && !strings.HasSuffix(lower, "ps") {
+			window, err := network.ParseSize(args[1])
+			if err != nil {
+				return "", err
+			}
+			bps, err := network.WindowThroughput(window/8, rtt)
+			if err != nil {
+				return "", err
+			}
+
+			return fmt.Sprintf("Window: %s\nRTT: %s\nMaximum rate: %s", network.FormatSize(window), network.FormatSeconds(rtt), network.FormatRate(bps)), nil
+		}
+
+		bps, err := network.ParseRate(args[1])
+		if err != nil {
+			return "", err
+		}
+		bdp := network.BandwidthDelayProduct(bps, rtt)
+
+		text := fmt.Sprintf("Rate: %s\nRTT: %s\nBandwidth-delay product: %s\nFull-size 1500 bytes packets in flight: %.0f", network.FormatRate(bps), network.FormatSeconds(rtt), network.FormatSize(bdp), bdp/8/1500)
+		if scale, ok := network.WindowScale(bdp / 8); ok {
+			text += fmt.Sprintf("\nTCP window needed: %s, window scale %d", network.FormatSize(bdp), scale)
+		} else {
+			text += "\nTCP window needed: bigger than the largest TCP window (1 GiB), use parallel connections"
+		}
+		if unscaled, err := network.WindowThroughput(65535, rtt); err == nil {
+			text += "\nWithout window scaling (64 KiB) the rate is at most " + network.FormatRate(unscaled)
+		}
+
+		return text, nil
+	}
+
+	return "", fmt.Errorf("unknown calculation %s", args[0])
+}
+
+// handleBandwidth answers "/bw <calculation> <values>" with transfer times, rates, bandwidth-delay products and
+// TCP windows
+func (tg *Telegram) handleBandwidth(message *tgbotapi.Message) {
+	text := bandwidthUsage
+	if args := quantityArgs(strings.Fields(message.Text)[1:]); len(args) > 0 {
+		var err error
+		if text, err = bandwidthText(args); err != nil {
+			text = "ERROR: " + err.Error() + ".\n\n" + bandwidthUsage
+		}
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+}
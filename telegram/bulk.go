@@ -0,0 +1,104 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package telegram
+
+import (
+	"fmt"
+	"go-Telegram-NetworkCalculator-bot/network"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// handleBulk answers "/bulk [csv|json|xlsx]" or a document sent in a private chat with the calculation of every
+// address or prefix of the document, the message or the replied-to message, as a document in the requested format
+func (tg *Telegram) handleBulk(message *tgbotapi.Message) {
+	content, err := tg.messageContent(message)
+	if strings.HasPrefix(strings.ToLower(content), "/bulk") {
+		content = textAfterFields(content, 1)
+	}
+	if err == nil && message.ReplyToMessage != nil {
+		var replyContent string
+		replyContent, err = tg.messageContent(message.ReplyToMessage)
+		content += "\n" + replyContent
+	}
+
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "ERROR: "+err.Error()+".")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	// The format can be written after the command or as caption of the document
+	format := "csv"
+	for _, field := range strings.Fields(message.Text) {
+		switch strings.ToLower(field) {
+		case "csv", "json", "xlsx":
+			format = strings.ToLower(field)
+		}
+	}
+
+	rows, invalid := network.CalculateBulk(content)
+	if len(rows) == 0 && len(invalid) == 0 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /bulk [csv|json|xlsx] <addresses or prefixes, one per row>\nSend a CSV or text file (in a private chat, or with /bulk as caption) or reply to one to calculate thousands of networks at once.")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	text := fmt.Sprintf("Calculated networks: %d\nInvalid rows: %d", len(rows), len(invalid))
+	for i, row := range invalid {
+		if i == maxPlanLines {
+			text += "\n…"
+			break
+		}
+		text += fmt.Sprintf("\nLine %d: %s (%s)", row.Line, row.Input, row.Err)
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+
+	if len(rows) == 0 {
+		return
+	}
+
+	var output []byte
+	switch format {
+	case "json":
+		output, err = network.BulkJSON(rows)
+	case "xlsx":
+		table := [][]string{network.BulkHeader}
+		for _, row := range rows {
+			table = append(table, row.Columns())
+		}
+		output, err = xlsxWorkbook("Networks", table)
+	default:
+		output, err = network.BulkCSV(rows)
+	}
+
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "ERROR generating the "+strings.ToUpper(format)+": "+err.Error()+".")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	document := tgbotapi.NewDocumentUpload(message.Chat.ID, tgbotapi.FileBytes{Name: "networks." + format, Bytes: output})
+	document.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(document)
+}
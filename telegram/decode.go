@@ -0,0 +1,86 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package telegram
+
+import (
+	"go-Telegram-NetworkCalculator-bot/packet"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Longer decodings are sent as a document, Telegram refuses messages longer than 4096 characters
+const maxDecodeLength = 3500
+
+// handleDecode answers "/decode <hex>", or a .bin document, with the fields of the packet. The hex can also be in a
+// text document or in the replied-to message
+func (tg *Telegram) handleDecode(message *tgbotapi.Message) {
+	document := message.Document
+	if document == nil && message.ReplyToMessage != nil {
+		document = message.ReplyToMessage.Document
+	}
+
+	var data []byte
+	var err error
+	if document != nil && strings.HasSuffix(strings.ToLower(document.FileName), ".bin") {
+		// Raw bytes of the packet
+		data, err = tg.downloadDocument(document)
+	} else {
+		// Skip the command, the rest is the hex of the packet
+		var content string
+		content, err = tg.messageContent(message)
+		content = textAfterFields(content, 1)
+		if err == nil && content == "" && message.ReplyToMessage != nil {
+			content, err = tg.messageContent(message.ReplyToMessage)
+		}
+
+		if err == nil && strings.TrimSpace(content) == "" {
+			msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /decode <hex>\nThe packet can be a Wireshark hex stream (Copy → as a Hex Stream), a tcpdump -X or -XX dump, or spaced hex bytes, from an Ethernet, IPv4 or IPv6 header. Raw bytes can be sent as a .bin document.")
+			msg.ReplyToMessageID = message.MessageID
+			_, _ = tg.api.Send(msg)
+			return
+		}
+
+		if err == nil {
+			data, err = packet.ParseHex(content)
+		}
+	}
+
+	var layers []*packet.Field
+	if err == nil {
+		layers, err = packet.Decode(data)
+	}
+
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "ERROR: "+err.Error()+".")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	tree := packet.Format(layers)
+	if len(tree) > maxDecodeLength {
+		document := tgbotapi.NewDocumentUpload(message.Chat.ID, tgbotapi.FileBytes{Name: "decode.txt", Bytes: []byte(tree + "\n")})
+		document.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(document)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, markdownCodeBlock("", tree))
+	msg.ParseMode = tgbotapi.ModeMarkdownV2
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+}
@@ -0,0 +1,242 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package telegram
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-Telegram-NetworkCalculator-bot/network"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// DHCP server formats supported by /dhcp, with the generator and the name of the document sent
+var dhcpFormats = []struct {
+	Name      string
+	FileName  string
+	Generator func(network.DhcpScope) string
+}{
+	{"isc", "dhcpd.conf", iscDhcpdConfig},
+	{"kea", "kea-dhcp4.json", keaConfig},
+	{"dnsmasq", "dnsmasq.conf", dnsmasqConfig},
+	{"netsh", "dhcp-scope.cmd", netshConfig},
+}
+
+// handleDhcp answers "/dhcp <cidr> [gateway first|last|none|<ip>] [reserve <range>,...] [dns <ip>,...] [format <name>]" with the DHCP server configs
+func (tg *Telegram) handleDhcp(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+	if len(args) < 2 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /dhcp <network>/<prefix> [gateway first|last|none|<address>] [reserve <first>-<last>,...] [dns <address>,...] [format isc|kea|dnsmasq|netsh]")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	// Parse the optional parameters
+	var gateway, format string
+	var reserved, dns []string
+	for i := 2; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			msg := tgbotapi.NewMessage(message.Chat.ID, "Missing value after \""+args[i]+"\"")
+			msg.ReplyToMessageID = message.MessageID
+			_, _ = tg.api.Send(msg)
+			return
+		}
+
+		switch strings.ToLower(args[i]) {
+		case "gateway", "gw":
+			gateway = args[i+1]
+		case "reserve", "reserved":
+			reserved = append(reserved, strings.Split(args[i+1], ",")...)
+		case "dns":
+			dns = append(dns, strings.Split(args[i+1], ",")...)
+		case "format":
+			format = strings.ToLower(args[i+1])
+			if !knownDhcpFormat(format) {
+				msg := tgbotapi.NewMessage(message.Chat.ID, "Unknown format, use one of: isc, kea, dnsmasq, netsh")
+				msg.ReplyToMessageID = message.MessageID
+				_, _ = tg.api.Send(msg)
+				return
+			}
+		default:
+			msg := tgbotapi.NewMessage(message.Chat.ID, "Unknown parameter: "+args[i])
+			msg.ReplyToMessageID = message.MessageID
+			_, _ = tg.api.Send(msg)
+			return
+		}
+	}
+
+	scope, err := network.PlanDhcpScope(args[1], gateway, reserved, dns)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "ERROR: "+err.Error()+".")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	// Summary of the scope
+	text := fmt.Sprintf("Network: %s/%d\n", network.ByteArrToStr(scope.Info.Network), scope.Info.Netmask.Decimal)
+	if scope.Gateway != nil {
+		text += "Gateway: " + network.ByteArrToStr(scope.Gateway) + "\n"
+	}
+	for _, pool := range scope.Pools {
+		text += "Pool: " + pool.String() + "\n"
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+
+	// Send a document for every requested format
+	for _, dhcpFormat := range dhcpFormats {
+		if format != "" && format != dhcpFormat.Name {
+			continue
+		}
+
+		document := tgbotapi.NewDocumentUpload(message.Chat.ID, tgbotapi.FileBytes{Name: dhcpFormat.FileName, Bytes: []byte(dhcpFormat.Generator(scope))})
+		document.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(document)
+	}
+}
+
+// knownDhcpFormat checks if a format is one of dhcpFormats
+func knownDhcpFormat(format string) bool {
+	for _, dhcpFormat := range dhcpFormats {
+		if format == dhcpFormat.Name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dnsServers returns the DNS servers of a scope joined with sep
+func dnsServers(scope network.DhcpScope, sep string) string {
+	servers := make([]string, 0, len(scope.Dns))
+	for _, server := range scope.Dns {
+		servers = append(servers, network.ByteArrToStr(server))
+	}
+
+	return strings.Join(servers, sep)
+}
+
+// iscDhcpdConfig generates the subnet declaration for ISC dhcpd
+func iscDhcpdConfig(scope network.DhcpScope) string {
+	var config strings.Builder
+
+	fmt.Fprintf(&config, "subnet %s netmask %s {\n", network.ByteArrToStr(scope.Info.Network), network.ByteArrToStr(scope.Info.Netmask.Dotted))
+	for _, pool := range scope.Pools {
+		fmt.Fprintf(&config, "  range %s %s;\n", network.ByteArrToStr(pool.First), network.ByteArrToStr(pool.Last))
+	}
+	if scope.Gateway != nil {
+		fmt.Fprintf(&config, "  option routers %s;\n", network.ByteArrToStr(scope.Gateway))
+	}
+	fmt.Fprintf(&config, "  option subnet-mask %s;\n", network.ByteArrToStr(scope.Info.Netmask.Dotted))
+	fmt.Fprintf(&config, "  option broadcast-address %s;\n", network.ByteArrToStr(scope.Info.Broadcast))
+	if len(scope.Dns) > 0 {
+		fmt.Fprintf(&config, "  option domain-name-servers %s;\n", dnsServers(scope, ", "))
+	}
+	config.WriteString("}\n")
+
+	return config.String()
+}
+
+// keaConfig generates the Dhcp4 configuration for Kea
+func keaConfig(scope network.DhcpScope) string {
+	type keaOption struct {
+		Name string `json:"name"`
+		Data string `json:"data"`
+	}
+	type keaPool struct {
+		Pool string `json:"pool"`
+	}
+	type keaSubnet struct {
+		ID         int         `json:"id"`
+		Subnet     string      `json:"subnet"`
+		Pools      []keaPool   `json:"pools"`
+		OptionData []keaOption `json:"option-data"`
+	}
+
+	subnet := keaSubnet{
+		ID:         1,
+		Subnet:     fmt.Sprintf("%s/%d", network.ByteArrToStr(scope.Info.Network), scope.Info.Netmask.Decimal),
+		Pools:      make([]keaPool, 0),
+		OptionData: make([]keaOption, 0),
+	}
+	for _, pool := range scope.Pools {
+		subnet.Pools = append(subnet.Pools, keaPool{network.ByteArrToStr(pool.First) + " - " + network.ByteArrToStr(pool.Last)})
+	}
+	if scope.Gateway != nil {
+		subnet.OptionData = append(subnet.OptionData, keaOption{"routers", network.ByteArrToStr(scope.Gateway)})
+	}
+	if len(scope.Dns) > 0 {
+		subnet.OptionData = append(subnet.OptionData, keaOption{"domain-name-servers", dnsServers(scope, ", ")})
+	}
+
+	config := map[string]interface{}{
+		"Dhcp4": map[string]interface{}{
+			"subnet4": []keaSubnet{subnet},
+		},
+	}
+
+	jsonConfig, _ := json.MarshalIndent(config, "", "  ")
+
+	return string(jsonConfig) + "\n"
+}
+
+// dnsmasqConfig generates the DHCP options for dnsmasq
+func dnsmasqConfig(scope network.DhcpScope) string {
+	var config strings.Builder
+
+	for _, pool := range scope.Pools {
+		fmt.Fprintf(&config, "dhcp-range=%s,%s,%s,12h\n", network.ByteArrToStr(pool.First), network.ByteArrToStr(pool.Last), network.ByteArrToStr(scope.Info.Netmask.Dotted))
+	}
+	if scope.Gateway != nil {
+		fmt.Fprintf(&config, "dhcp-option=option:router,%s\n", network.ByteArrToStr(scope.Gateway))
+	}
+	if len(scope.Dns) > 0 {
+		fmt.Fprintf(&config, "dhcp-option=option:dns-server,%s\n", dnsServers(scope, ","))
+	}
+
+	return config.String()
+}
+
+// netshConfig generates the netsh commands that create the scope on a Windows DHCP server
+func netshConfig(scope network.DhcpScope) string {
+	var config strings.Builder
+	scopeID := network.ByteArrToStr(scope.Info.Network)
+
+	// Windows scopes have a single range, the gaps between the pools become exclusions
+	first := scope.Pools[0].First
+	last := scope.Pools[len(scope.Pools)-1].Last
+
+	fmt.Fprintf(&config, "netsh dhcp server add scope %s %s \"%s/%d\" \"\"\r\n", scopeID, network.ByteArrToStr(scope.Info.Netmask.Dotted), scopeID, scope.Info.Netmask.Decimal)
+	fmt.Fprintf(&config, "netsh dhcp server scope %s add iprange %s %s\r\n", scopeID, network.ByteArrToStr(first), network.ByteArrToStr(last))
+	for i := 1; i < len(scope.Pools); i++ {
+		excludeFirst := network.Uint32ToIP(network.IPToUint32(scope.Pools[i-1].Last) + 1)
+		excludeLast := network.Uint32ToIP(network.IPToUint32(scope.Pools[i].First) - 1)
+		fmt.Fprintf(&config, "netsh dhcp server scope %s add excluderange %s %s\r\n", scopeID, network.ByteArrToStr(excludeFirst), network.ByteArrToStr(excludeLast))
+	}
+	if scope.Gateway != nil {
+		fmt.Fprintf(&config, "netsh dhcp server scope %s set optionvalue 003 IPADDRESS %s\r\n", scopeID, network.ByteArrToStr(scope.Gateway))
+	}
+	if len(scope.Dns) > 0 {
+		fmt.Fprintf(&config, "netsh dhcp server scope %s set optionvalue 006 IPADDRESS %s\r\n", scopeID, dnsServers(scope, " "))
+	}
+
+	return config.String()
+}
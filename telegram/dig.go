@@ -0,0 +1,134 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package telegram
+
+import (
+	"fmt"
+	"go-Telegram-NetworkCalculator-bot/network"
+	"go-Telegram-NetworkCalculator-bot/resolver"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Maximum quantity of /calc buttons under an answer of /dig
+const maxDigButtons = 8
+
+// handleDig answers "/dig <name> [type]" with the records of a name from the configured resolver. The addresses are
+// looked up as PTR, and the addresses of the answer have a button showing their /calc
+func (tg *Telegram) handleDig(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+	if len(args) < 2 {
+		types := make([]string, 0, len(resolver.Types))
+		for name := range resolver.Types {
+			types = append(types, name)
+		}
+		sort.Strings(types)
+
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /dig <name|address> [type]\nTypes: "+strings.Join(types, ", ")+". The default is A, or PTR for an address.")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	name, typeName := args[1], "A"
+	if ip := net.ParseIP(name); ip != nil {
+		name, typeName = resolver.ReverseName(ip), "PTR"
+	}
+	if len(args) >= 3 {
+		typeName = strings.ToUpper(args[2])
+	}
+
+	var response *resolver.Response
+	qtype, found := resolver.Types[typeName]
+	err := fmt.Errorf("unsupported record type %s", typeName)
+	if found {
+		response, err = tg.resolver.Query(name, qtype)
+	}
+
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "ERROR: "+err.Error()+".")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	lines := []string{fmt.Sprintf("%s %s: %s, flags %s, %d ms from %s", name, typeName, response.Rcode, strings.Join(response.Flags, " "), response.Duration.Milliseconds(), response.Server)}
+	records := response.Answers
+	if len(records) == 0 && len(response.Authority) > 0 {
+		// Without answers the authority section has the SOA of the zone, its TTL is the one of the negative answer
+		lines = append(lines, "No answer, authority:")
+		records = response.Authority
+	}
+
+	buttons := make([]tgbotapi.InlineKeyboardButton, 0)
+	for i, record := range records {
+		if i == maxPlanLines {
+			lines = append(lines, "…")
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%s %d %s %s", record.Name, record.TTL, record.Type, record.Data))
+
+		if record.IP != nil && len(buttons) < maxDigButtons {
+			if record.IP.To4() != nil {
+				buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData("🧮 "+record.Data+"/24", "calc "+record.Data+" 255.255.255.0"))
+			} else {
+				buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData("🧮 "+record.Data+"/64", "calc "+record.Data+" 64"))
+			}
+		}
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, strings.Join(lines, "\n"))
+	msg.ReplyToMessageID = message.MessageID
+	if len(buttons) > 0 {
+		rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(buttons))
+		for _, button := range buttons {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(button))
+		}
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	}
+	_, _ = tg.api.Send(msg)
+}
+
+// handleCalcCallback runs /calc on the address of a button, as if the user sent the command. /calc supports only
+// IPv4, so the IPv6 addresses are described with their prefix instead
+func (tg *Telegram) handleCalcCallback(query *tgbotapi.CallbackQuery, args []string) {
+	_, _ = tg.api.AnswerCallbackQuery(tgbotapi.NewCallback(query.ID, ""))
+	if query.Message == nil || len(args) < 2 || net.ParseIP(args[0]) == nil {
+		return
+	}
+
+	if ip := net.ParseIP(args[0]); ip.To4() == nil {
+		length, err := strconv.Atoi(args[1])
+		if err != nil || length < 0 || length > 128 {
+			return
+		}
+
+		mask := net.CIDRMask(length, 128)
+		msg := tgbotapi.NewMessage(query.Message.Chat.ID, network.DescribePrefix(&net.IPNet{IP: ip.Mask(mask), Mask: mask}))
+		msg.ReplyToMessageID = query.Message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	message := *query.Message
+	message.From = query.From
+	message.Text = "/calc " + args[0] + " " + args[1]
+	tg.HandleUpdate(tgbotapi.Update{Message: &message})
+}
@@ -0,0 +1,93 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"go-Telegram-NetworkCalculator-bot/config"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Error shown to the users when a document can't be downloaded
+var errDownload = errors.New("download of the document failed")
+
+// Client of the document downloads, a stalled download would block its handler forever
+var documentClient = &http.Client{Timeout: config.DocumentTimeout * time.Second}
+
+// downloadDocument returns the content of a document sent to the bot, refusing the ones bigger than config.DocumentMaxSize
+func (tg *Telegram) downloadDocument(document *tgbotapi.Document) ([]byte, error) {
+	if document.FileSize > config.DocumentMaxSize {
+		return nil, fmt.Errorf("the document is bigger than %d KiB", config.DocumentMaxSize/1024)
+	}
+
+	// The URL of the file contains the token of the bot, so the errors are logged and the users get a generic one
+	fileURL, err := tg.api.GetFileDirectURL(document.FileID)
+	if err != nil {
+		fmt.Println("unable to get the URL of a document:", err)
+		return nil, errDownload
+	}
+
+	response, err := documentClient.Get(fileURL)
+	if err != nil {
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		fmt.Println("unable to download a document:", err)
+		return nil, errDownload
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download of the document failed with status %s", response.Status)
+	}
+
+	// The size declared by Telegram can be missing, so never read more than the limit
+	content, err := ioutil.ReadAll(io.LimitReader(response.Body, config.DocumentMaxSize+1))
+	if err != nil {
+		fmt.Println("unable to download a document:", err)
+		return nil, errDownload
+	}
+	if len(content) > config.DocumentMaxSize {
+		return nil, fmt.Errorf("the document is bigger than %d KiB", config.DocumentMaxSize/1024)
+	}
+
+	return content, nil
+}
+
+// messageContent returns the text (or caption) of a message followed by its attached document, as a single text
+func (tg *Telegram) messageContent(message *tgbotapi.Message) (string, error) {
+	text := message.Text
+	if text == "" {
+		text = message.Caption
+	}
+
+	if message.Document != nil {
+		content, err := tg.downloadDocument(message.Document)
+		if err != nil {
+			return "", err
+		}
+		text += "\n" + string(content)
+	}
+
+	return text, nil
+}
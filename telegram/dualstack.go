@@ -0,0 +1,122 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package telegram
+
+import (
+	"fmt"
+	"go-Telegram-NetworkCalculator-bot/network"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// handleDualStack answers "/dualstack <ipv4 cidr> <ipv6 cidr> <vlan>:<hosts>[:name]... [csv] [json]" with the paired plan
+func (tg *Telegram) handleDualStack(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+	if len(args) < 4 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /dualstack <IPv4 network>/<prefix> <IPv6 network>/<prefix> <VLAN ID>:<hosts>[:name]... [csv] [json]\nExample: /dualstack 10.20.0.0/16 2001:db8:abcd::/48 10:200:users 120:30:printers")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	// Parse the VLANs and the export formats
+	vlans := make([]network.VlanRequest, 0)
+	csv, json := false, false
+	for _, arg := range args[3:] {
+		switch strings.ToLower(arg) {
+		case "csv":
+			csv = true
+			continue
+		case "json":
+			json = true
+			continue
+		}
+
+		parts := strings.SplitN(arg, ":", 3)
+		var vlan network.VlanRequest
+		var err error
+		if len(parts) >= 2 {
+			vlan.ID, err = strconv.ParseUint(parts[0], 10, 12)
+			if err == nil {
+				vlan.Hosts, err = strconv.ParseUint(parts[1], 10, 64)
+			}
+		}
+		if len(parts) < 2 || err != nil || vlan.ID == 0 {
+			msg := tgbotapi.NewMessage(message.Chat.ID, "Invalid VLAN "+arg+", use <VLAN ID>:<hosts>[:name]")
+			msg.ReplyToMessageID = message.MessageID
+			_, _ = tg.api.Send(msg)
+			return
+		}
+		if len(parts) == 3 {
+			vlan.Name = parts[2]
+		}
+
+		vlans = append(vlans, vlan)
+	}
+
+	plan, err := network.PlanDualStack(args[1], args[2], vlans)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "ERROR: "+err.Error()+".")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	// Show the table ordered by VLAN ID
+	rows := make([]network.DualStackVlan, len(plan.Vlans))
+	copy(rows, plan.Vlans)
+	sort.Slice(rows, func(a, b int) bool {
+		return rows[a].ID < rows[b].ID
+	})
+
+	var table strings.Builder
+	writer := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "VLAN\tName\tHosts\tIPv4\tUsable\tIPv6")
+	for _, vlan := range rows {
+		fmt.Fprintf(writer, "%d\t%s\t%d\t%s\t%d\t%s\n", vlan.ID, vlan.Name, vlan.Hosts, vlan.IPv4, vlan.Usable, vlan.IPv6)
+	}
+	_ = writer.Flush()
+
+	text := escapeMarkdown(fmt.Sprintf("IPv4: %s\nIPv6: %s (the subnet ID repeats the VLAN ID digits)\n", plan.IPv4, plan.IPv6))
+	msg := tgbotapi.NewMessage(message.Chat.ID, text+markdownCodeBlock("", strings.TrimRight(table.String(), "\n")))
+	msg.ParseMode = tgbotapi.ModeMarkdownV2
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+
+	if csv {
+		document := tgbotapi.NewDocumentUpload(message.Chat.ID, tgbotapi.FileBytes{Name: "dualstack.csv", Bytes: []byte(plan.CSV())})
+		document.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(document)
+	}
+
+	if json {
+		jsonPlan, err := plan.JSON()
+		if err != nil {
+			msg := tgbotapi.NewMessage(message.Chat.ID, "ERROR generating the JSON: "+err.Error()+".")
+			msg.ReplyToMessageID = message.MessageID
+			_, _ = tg.api.Send(msg)
+			return
+		}
+
+		document := tgbotapi.NewDocumentUpload(message.Chat.ID, tgbotapi.FileBytes{Name: "dualstack.json", Bytes: jsonPlan})
+		document.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(document)
+	}
+}
@@ -0,0 +1,123 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package telegram
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"go-Telegram-NetworkCalculator-bot/network"
+	"net"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Inline buttons offered with the extracted addresses, their data is "extract <action>"
+var extractKeyboard = tgbotapi.NewInlineKeyboardMarkup(
+	tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🧮 Calc each", "extract calc"),
+		tgbotapi.NewInlineKeyboardButtonData("🏷 Classify", "extract classify"),
+	),
+	tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🗜 Aggregate", "extract aggregate"),
+		tgbotapi.NewInlineKeyboardButtonData("📄 Export", "extract export"),
+	),
+)
+
+// handleExtract answers a free text, like a forwarded log excerpt, with its addresses and prefixes and the buttons to
+// work on them. Texts without addresses are ignored
+func (tg *Telegram) handleExtract(message *tgbotapi.Message) {
+	prefixes := network.ExtractPrefixes(message.Text + "\n" + message.Caption)
+	if len(prefixes) == 0 {
+		return
+	}
+
+	text := fmt.Sprintf("Found %d addresses and prefixes:", len(prefixes))
+	for i, prefix := range prefixes {
+		if i == maxPlanLines {
+			text += "\n…"
+			break
+		}
+		text += "\n" + network.PrefixString(prefix)
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	msg.ReplyMarkup = extractKeyboard
+	_, _ = tg.api.Send(msg)
+}
+
+// handleExtractCallback runs the action of a button sent by handleExtract on the addresses of the original message
+func (tg *Telegram) handleExtractCallback(query *tgbotapi.CallbackQuery, args []string) {
+	_, _ = tg.api.AnswerCallbackQuery(tgbotapi.NewCallback(query.ID, ""))
+	if query.Message == nil || len(args) == 0 {
+		return
+	}
+
+	// The original message is the one the bot replied to, the list of the reply is used if it's no longer available
+	source := query.Message.Text
+	if original := query.Message.ReplyToMessage; original != nil {
+		source = original.Text + "\n" + original.Caption
+	}
+	prefixes := network.ExtractPrefixes(source)
+	if len(prefixes) == 0 {
+		return
+	}
+
+	lines := make([]string, 0, len(prefixes))
+	switch args[0] {
+	case "calc":
+		for _, prefix := range prefixes {
+			lines = append(lines, network.DescribePrefix(prefix))
+		}
+	case "classify":
+		for _, prefix := range prefixes {
+			lines = append(lines, network.PrefixString(prefix)+": "+network.ClassifyIP(prefix.IP))
+		}
+	case "aggregate":
+		networks := make([]*net.IPNet, 0, len(prefixes))
+		for _, prefix := range prefixes {
+			networks = append(networks, &net.IPNet{IP: prefix.IP.Mask(prefix.Mask), Mask: prefix.Mask})
+		}
+		for _, prefix := range network.CollapsePrefixes(networks) {
+			lines = append(lines, network.PrefixString(prefix))
+		}
+	case "export":
+		var buffer bytes.Buffer
+		writer := csv.NewWriter(&buffer)
+		_ = writer.Write([]string{"entry", "network", "classification"})
+		for _, prefix := range prefixes {
+			_ = writer.Write([]string{network.PrefixString(prefix), (&net.IPNet{IP: prefix.IP.Mask(prefix.Mask), Mask: prefix.Mask}).String(), network.ClassifyIP(prefix.IP)})
+		}
+		writer.Flush()
+
+		document := tgbotapi.NewDocumentUpload(query.Message.Chat.ID, tgbotapi.FileBytes{Name: "addresses.csv", Bytes: buffer.Bytes()})
+		document.ReplyToMessageID = query.Message.MessageID
+		_, _ = tg.api.Send(document)
+		return
+	default:
+		return
+	}
+
+	if len(lines) > maxPlanLines {
+		lines = append(lines[:maxPlanLines], "…")
+	}
+
+	msg := tgbotapi.NewMessage(query.Message.Chat.ID, strings.Join(lines, "\n"))
+	msg.ReplyToMessageID = query.Message.MessageID
+	_, _ = tg.api.Send(msg)
+}
@@ -0,0 +1,119 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package telegram
+
+import (
+	"fmt"
+	"go-Telegram-NetworkCalculator-bot/network"
+	"sort"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Maximum quantity of free blocks listed by /findfree
+const maxFreeBlocks = 50
+
+// extractPrefixes returns every IPv4 prefix in a text, like a list or the output of "show ip route connected"
+func extractPrefixes(text string) []network.Block {
+	blocks := make([]network.Block, 0)
+
+	for _, prefix := range network.RoutePrefixes(text) {
+		if block, err := network.ParseBlock(prefix.String()); err == nil {
+			blocks = append(blocks, block)
+		}
+	}
+
+	return blocks
+}
+
+// handleFindFree answers "/findfree <parent> [size|largest] <allocated...>" with the free blocks of parent
+func (tg *Telegram) handleFindFree(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+	if len(args) < 2 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /findfree <parent network>/<prefix> [size or \"largest\"] <allocated prefixes...>\nThe allocated prefixes can be pasted from \"show ip route connected\" or sent in the message you reply to.")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	parent, err := network.ParseBlock(args[1])
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "ERROR: "+err.Error()+".")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	// The optional size is the second argument, the allocated prefixes are in the rest of the text
+	var size uint64
+	allocatedText := textAfterFields(message.Text, 2)
+	if len(args) >= 3 && !strings.Contains(args[2], ".") {
+		if strings.ToLower(args[2]) != "largest" {
+			size, err = strconv.ParseUint(strings.TrimPrefix(args[2], "/"), 10, 8)
+			if err != nil || size > 32 || uint8(size) < parent.Netmask.Decimal {
+				msg := tgbotapi.NewMessage(message.Chat.ID, "Invalid size: "+args[2])
+				msg.ReplyToMessageID = message.MessageID
+				_, _ = tg.api.Send(msg)
+				return
+			}
+		}
+		allocatedText = textAfterFields(message.Text, 3)
+	}
+
+	if message.ReplyToMessage != nil {
+		allocatedText += "\n" + message.ReplyToMessage.Text + "\n" + message.ReplyToMessage.Caption
+	}
+
+	allocated := extractPrefixes(allocatedText)
+	space := network.FindFree(parent, allocated)
+
+	text := fmt.Sprintf("Parent: %s\nAllocated prefixes: %d\nFree: %d of %d addresses in %d ranges", parent, len(allocated), space.Free, parent.Size(), len(space.Ranges))
+	if space.Free == 0 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, text)
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+	text += fmt.Sprintf("\nLargest free block: %s\nFragmentation: %.1f%% of the free space is outside the largest block\n", space.Largest, space.Fragmentation())
+
+	var blocks []network.Block
+	if size > 0 {
+		var quantity uint64
+		blocks, quantity = space.BlocksOf(uint8(size), maxFreeBlocks)
+		text += fmt.Sprintf("\nFree /%d blocks: %d", size, quantity)
+	} else {
+		// Biggest free blocks first
+		blocks = make([]network.Block, len(space.Blocks))
+		copy(blocks, space.Blocks)
+		sort.SliceStable(blocks, func(a, b int) bool {
+			return blocks[a].Netmask.Decimal < blocks[b].Netmask.Decimal
+		})
+		if len(blocks) > maxFreeBlocks {
+			blocks = blocks[:maxFreeBlocks]
+		}
+		text += "\nLargest free blocks:"
+	}
+
+	for _, block := range blocks {
+		text += "\n" + block.String()
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+}
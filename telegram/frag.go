@@ -0,0 +1,98 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package telegram
+
+import (
+	"fmt"
+	"go-Telegram-NetworkCalculator-bot/network"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// handleFrag answers "/frag <payload-size> <mtu> [header-len]" with the IPv4 fragments of a datagram
+func (tg *Telegram) handleFrag(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+
+	values := make([]int, 0, 3)
+	for _, arg := range args[1:] {
+		value, err := strconv.Atoi(arg)
+		if err != nil {
+			break
+		}
+		values = append(values, value)
+	}
+
+	if len(values) < 2 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /frag <payload-size> <mtu> [header-len]\nThe payload is the data after the IPv4 header, like an ICMP message or a UDP datagram with its header. The header is 20 bytes unless it has options.")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	payload, mtu, header := values[0], values[1], 20
+	if len(values) >= 3 {
+		header = values[2]
+	}
+
+	fragments, err := network.FragmentDatagram(payload, mtu, header)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "ERROR: "+err.Error()+".")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	var text string
+	if len(fragments) == 1 {
+		text = fmt.Sprintf("The datagram is %d bytes (%d header + %d payload) and fits in the MTU of %d: no fragmentation.\n", payload+header, header, payload, mtu)
+	} else {
+		step := network.FragmentData(mtu, header)
+		text = fmt.Sprintf("The datagram is %d bytes (%d header + %d payload), bigger than the MTU of %d.\n", payload+header, header, payload, mtu)
+		text += fmt.Sprintf("Every fragment has its own %d bytes header, leaving %d - %d = %d bytes for the data. ", header, mtu, header, mtu-header)
+		if step != mtu-header {
+			text += fmt.Sprintf("The offset field counts 8 bytes units, so the data of every fragment but the last is rounded down to a multiple of 8: %d bytes (%d units), and the fragments are %d bytes instead of %d. ", step, step/8, step+header, mtu)
+		} else {
+			text += fmt.Sprintf("It's already a multiple of 8, as required by the offset field that counts 8 bytes units: %d units. ", step/8)
+		}
+		text += fmt.Sprintf("The last fragment carries the remaining %d bytes, it has MF clear and needs no rounding.\n", fragments[len(fragments)-1].DataLength)
+		text += fmt.Sprintf("%d fragments, %d bytes on the wire (%d more than the original datagram).\n", len(fragments), payload+header*len(fragments), header*(len(fragments)-1))
+	}
+
+	var table strings.Builder
+	writer := tabwriter.NewWriter(&table, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(writer, "#\tTotal\tOffset\tOffset bytes\tMF\tData\t")
+	for i, fragment := range fragments {
+		if i == maxPlanLines {
+			fmt.Fprintf(writer, "…\t\t\t\t\t\t\n")
+			break
+		}
+
+		moreFragments := 0
+		if fragment.MoreFragments {
+			moreFragments = 1
+		}
+		fmt.Fprintf(writer, "%d\t%d\t%d\t%d\t%d\t%d\t\n", i+1, fragment.TotalLength, fragment.OffsetUnits(), fragment.Offset, moreFragments, fragment.DataLength)
+	}
+	_ = writer.Flush()
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, escapeMarkdown(text)+markdownCodeBlock("", strings.TrimRight(table.String(), "\n")))
+	msg.ParseMode = tgbotapi.ModeMarkdownV2
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+}
@@ -0,0 +1,102 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package telegram
+
+import (
+	"fmt"
+	"go-Telegram-NetworkCalculator-bot/geoip"
+	"net"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// handleGeo answers "/geo <ip>" with the location of an address from the local GeoIP database
+func (tg *Telegram) handleGeo(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+	if len(args) < 2 || net.ParseIP(args[1]) == nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /geo <address>")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	address := net.ParseIP(args[1])
+	record, found, err := tg.geo.Geo(address)
+
+	var text string
+	switch {
+	case err == geoip.ErrNoGeoDatabase:
+		text = "/geo is disabled: no GeoIP database is configured."
+	case err != nil:
+		text = "ERROR: " + err.Error() + "."
+	case !found:
+		text = address.String() + " isn't in the GeoIP database (private or unassigned address)."
+	default:
+		text = fmt.Sprintf("Address: %s\nNetwork: %s", address, record.Network)
+		for _, line := range [][2]string{
+			{"Continent", record.Continent},
+			{"Country", strings.TrimSpace(record.Country + " " + record.CountryCode)},
+			{"Region", record.Region},
+			{"City", strings.TrimSpace(record.City + " " + record.Postal)},
+			{"Time zone", record.TimeZone},
+		} {
+			if line[1] != "" {
+				text += "\n" + line[0] + ": " + line[1]
+			}
+		}
+		if record.AccuracyRadius > 0 {
+			text += fmt.Sprintf("\nLocation: %.4f, %.4f (± %d km)", record.Latitude, record.Longitude, record.AccuracyRadius)
+		}
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+}
+
+// handleAsn answers "/asn <ip>" with the autonomous system of an address from the local ASN databases
+func (tg *Telegram) handleAsn(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+	if len(args) < 2 || net.ParseIP(args[1]) == nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /asn <address>")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	address := net.ParseIP(args[1])
+	record, found, err := tg.geo.Asn(address)
+
+	var text string
+	switch {
+	case err == geoip.ErrNoAsnDatabase:
+		text = "/asn is disabled: no ASN database is configured."
+	case err != nil:
+		text = "ERROR: " + err.Error() + "."
+	case !found:
+		text = address.String() + " isn't announced by any autonomous system in the database."
+	default:
+		text = fmt.Sprintf("Address: %s\nNetwork: %s\nAS%d %s", address, record.Network, record.Number, record.Organization)
+		if record.Country != "" {
+			text += "\nCountry: " + record.Country
+		}
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+}
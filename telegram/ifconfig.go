@@ -0,0 +1,298 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package telegram
+
+import (
+	"fmt"
+	"go-Telegram-NetworkCalculator-bot/config"
+	"go-Telegram-NetworkCalculator-bot/network"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Data passed to the router templates
+type routerData struct {
+	Interface   string // Empty if the user didn't choose it, the templates use their own default
+	Address     string
+	Prefix      uint8
+	Cidr        string // Address with prefix
+	Netmask     string
+	Wildcard    string
+	Network     string
+	NetworkCidr string // Network with prefix
+	Broadcast   string
+	Area        string // OSPF area as a number
+	AreaDotted  string // OSPF area in dotted notation
+	Routes      []routeData
+}
+
+// Static route passed to the router templates
+type routeData struct {
+	Network  string
+	Prefix   uint8
+	Cidr     string
+	Netmask  string
+	Wildcard string
+	NextHop  string
+}
+
+// Built-in router templates, by vendor. More vendors can be added (or these overridden) with <vendor>.tmpl files in config.RouterTemplatesDir
+var routerTemplates = map[string]string{
+	"cisco": `{{$if := or .Interface "GigabitEthernet0/0"}}interface {{$if}}
+ ip address {{.Address}} {{.Netmask}}
+ no shutdown
+!
+{{range .Routes}}ip route {{.Network}} {{.Netmask}} {{.NextHop}}
+{{end}}!
+router ospf 1
+ network {{.Network}} {{.Wildcard}} area {{.Area}}
+`,
+	"junos": `{{$if := or .Interface "ge-0/0/0"}}set interfaces {{$if}} unit 0 family inet address {{.Cidr}}
+{{range .Routes}}set routing-options static route {{.Cidr}} next-hop {{.NextHop}}
+{{end}}set protocols ospf area {{.AreaDotted}} interface {{$if}}.0
+`,
+	"mikrotik": `{{$if := or .Interface "ether1"}}{{$area := printf "area%s" .Area}}{{if eq .Area "0"}}{{$area = "backbone"}}{{end}}/ip address add address={{.Cidr}} interface={{$if}}
+{{range .Routes}}/ip route add dst-address={{.Cidr}} gateway={{.NextHop}}
+{{end}}/routing ospf instance add name=default-v2
+/routing ospf area add name={{$area}} area-id={{.AreaDotted}} instance=default-v2
+/routing ospf interface-template add area={{$area}} networks={{.NetworkCidr}}
+`,
+	"vyos": `{{$if := or .Interface "eth0"}}set interfaces ethernet {{$if}} address {{.Cidr}}
+{{range .Routes}}set protocols static route {{.Cidr}} next-hop {{.NextHop}}
+{{end}}set protocols ospf area {{.Area}} network {{.NetworkCidr}}
+`,
+	"linux": `{{$if := or .Interface "eth0"}}# iproute2
+ip address add {{.Cidr}} dev {{$if}}
+ip link set {{$if}} up
+{{range .Routes}}ip route add {{.Cidr}} via {{.NextHop}}
+{{end}}
+# netplan
+network:
+  version: 2
+  ethernets:
+    {{$if}}:
+      addresses:
+        - {{.Cidr}}
+{{- if .Routes}}
+      routes:
+{{- range .Routes}}
+        - to: {{.Cidr}}
+          via: {{.NextHop}}
+{{- end}}
+{{- end}}
+
+# FRR (vtysh)
+router ospf
+ network {{.NetworkCidr}} area {{.Area}}
+`,
+	"windows": `{{$if := or .Interface "Ethernet"}}netsh interface ipv4 set address name="{{$if}}" static {{.Address}} {{.Netmask}}
+{{range .Routes}}netsh interface ipv4 add route {{.Cidr}} "{{$if}}" {{.NextHop}}
+{{end}}rem Windows doesn't support OSPF, announce {{.NetworkCidr}} from the router
+`,
+}
+
+// loadRouterTemplates returns the built-in templates merged with the ones in config.RouterTemplatesDir
+func loadRouterTemplates() (map[string]string, error) {
+	templates := make(map[string]string, len(routerTemplates))
+	for vendor, text := range routerTemplates {
+		templates[vendor] = text
+	}
+
+	if config.RouterTemplatesDir == "" {
+		return templates, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(config.RouterTemplatesDir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		text, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		vendor := strings.ToLower(strings.TrimSuffix(filepath.Base(file), ".tmpl"))
+		templates[vendor] = string(text)
+	}
+
+	return templates, nil
+}
+
+// newRouterData fills the template data of an interface address
+func newRouterData(cidr string) (routerData, error) {
+	var data routerData
+
+	address, netmask, err := network.ParseCidr(cidr)
+	if err != nil {
+		return data, err
+	}
+
+	netInfo := network.CalculateNetwork(network.ByteArrToStr(address), network.ByteArrToStr(netmask.Dotted))
+
+	data.Address = network.ByteArrToStr(netInfo.Address)
+	data.Prefix = netmask.Decimal
+	data.Cidr = fmt.Sprintf("%s/%d", data.Address, data.Prefix)
+	data.Netmask = network.ByteArrToStr(netmask.Dotted)
+	data.Wildcard = network.ByteArrToStr(netInfo.Wildcard)
+	data.Network = network.ByteArrToStr(netInfo.Network)
+	data.NetworkCidr = fmt.Sprintf("%s/%d", data.Network, data.Prefix)
+	data.Broadcast = network.ByteArrToStr(netInfo.Broadcast)
+	data.Routes = make([]routeData, 0)
+	err = data.setArea("0")
+
+	return data, err
+}
+
+// setArea sets the OSPF area from a number or a dotted area ID
+func (data *routerData) setArea(area string) error {
+	if areaIP := net.ParseIP(area).To4(); areaIP != nil {
+		data.Area = strconv.FormatUint(uint64(network.IPToUint32(areaIP)), 10)
+		data.AreaDotted = areaIP.String()
+		return nil
+	}
+
+	areaNum, err := strconv.ParseUint(area, 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid OSPF area %s", area)
+	}
+
+	data.Area = strconv.FormatUint(areaNum, 10)
+	data.AreaDotted = network.ByteArrToStr(network.Uint32ToIP(uint32(areaNum)))
+
+	return nil
+}
+
+// addRoute adds a static route to the template data
+func (data *routerData) addRoute(destination string, nextHop string) error {
+	block, err := network.ParseBlock(destination)
+	if err != nil {
+		return err
+	}
+
+	if net.ParseIP(nextHop).To4() == nil {
+		return fmt.Errorf("invalid next hop %s", nextHop)
+	}
+
+	wildcard := make([]uint8, 0)
+	for _, maskPart := range block.Netmask.Dotted {
+		wildcard = append(wildcard, uint8(255)-maskPart)
+	}
+
+	data.Routes = append(data.Routes, routeData{
+		Network:  network.ByteArrToStr(block.Network),
+		Prefix:   block.Netmask.Decimal,
+		Cidr:     block.String(),
+		Netmask:  network.ByteArrToStr(block.Netmask.Dotted),
+		Wildcard: network.ByteArrToStr(wildcard),
+		NextHop:  nextHop,
+	})
+
+	return nil
+}
+
+// handleIfconfig answers "/ifconfig <vendor> <ip>/<prefix> [interface <name>] [area <id>] [route <cidr> <next hop>]..." with the router config
+func (tg *Telegram) handleIfconfig(message *tgbotapi.Message) {
+	templates, err := loadRouterTemplates()
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "ERROR loading the templates: "+err.Error()+".")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	args := strings.Fields(message.Text)
+	if len(args) < 3 {
+		vendors := make([]string, 0, len(templates))
+		for vendor := range templates {
+			vendors = append(vendors, vendor)
+		}
+		sort.Strings(vendors)
+
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /ifconfig <vendor> <address>/<prefix> [interface <name>] [area <id>] [route <network>/<prefix> <next hop>]...\nVendors: "+strings.Join(vendors, ", "))
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	templateText, found := templates[strings.ToLower(args[1])]
+	if !found {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Unknown vendor: "+args[1])
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	data, err := newRouterData(args[2])
+
+	// Parse the optional parameters
+	for i := 3; i < len(args) && err == nil; i += 2 {
+		if i+1 >= len(args) {
+			err = fmt.Errorf("missing value after \"%s\"", args[i])
+			break
+		}
+
+		switch strings.ToLower(args[i]) {
+		case "interface", "if":
+			data.Interface = args[i+1]
+		case "area":
+			err = data.setArea(args[i+1])
+		case "route":
+			if i+2 >= len(args) {
+				err = fmt.Errorf("missing next hop for the route to %s", args[i+1])
+				break
+			}
+			err = data.addRoute(args[i+1], args[i+2])
+			i++
+		default:
+			err = fmt.Errorf("unknown parameter %s", args[i])
+		}
+	}
+
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "ERROR: "+err.Error()+".")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	// Render the vendor template
+	var routerConfig strings.Builder
+	tmpl, err := template.New(args[1]).Parse(templateText)
+	if err == nil {
+		err = tmpl.Execute(&routerConfig, data)
+	}
+
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "ERROR in the "+args[1]+" template: "+err.Error()+".")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, markdownCodeBlock("", strings.TrimSpace(routerConfig.String())))
+	msg.ParseMode = tgbotapi.ModeMarkdownV2
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+}
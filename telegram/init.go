@@ -17,20 +17,37 @@ package telegram
 
 import (
 	"errors"
+	"go-Telegram-NetworkCalculator-bot/config"
+	"go-Telegram-NetworkCalculator-bot/geoip"
+	"go-Telegram-NetworkCalculator-bot/ipam"
+	"go-Telegram-NetworkCalculator-bot/network"
+	"go-Telegram-NetworkCalculator-bot/prefixsets"
+	"go-Telegram-NetworkCalculator-bot/rdap"
+	"go-Telegram-NetworkCalculator-bot/resolver"
 	"go-Telegram-NetworkCalculator-bot/roles"
+	"sync"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
 )
 
 // Telegram bot type
 type Telegram struct {
-	api *tgbotapi.BotAPI
-	db  *roles.Roles
+	api          *tgbotapi.BotAPI
+	db           *roles.Roles
+	pools        *ipam.Pools
+	reservations *ipam.Reservations
+	routes       map[int64]*network.RoutingTable // Routing tables uploaded in every chat, kept in memory
+	routesMutex  *sync.Mutex
+	geo          *geoip.Databases
+	prefixSets   *prefixsets.Sets
+	rdap         *rdap.Client
+	resolver     *resolver.Client
 }
 
 // NewTelegramBot create a new Telegram bot instance from a token
 // Returns a pointer to Telegram struct
-func NewTelegramBot(token string, database *roles.Roles) (*Telegram, error) {
+func NewTelegramBot(token string, database *roles.Roles, pools *ipam.Pools, reservations *ipam.Reservations, geo *geoip.Databases, prefixSets *prefixsets.Sets) (*Telegram, error) {
 	// Create new variables
 	bot := new(Telegram)
 	var err error
@@ -50,6 +67,46 @@ func NewTelegramBot(token string, database *roles.Roles) (*Telegram, error) {
 	// Assign roles to Telegram bot struct
 	bot.db = database
 
+	// Check if input pools pointer is valid
+	if pools == nil {
+		return nil, errors.New("pools pointer is nil, unable to configure bot")
+	}
+
+	// Assign pools to Telegram bot struct
+	bot.pools = pools
+
+	// Check if input reservations pointer is valid
+	if reservations == nil {
+		return nil, errors.New("reservations pointer is nil, unable to configure bot")
+	}
+
+	// Assign reservations to Telegram bot struct
+	bot.reservations = reservations
+
+	// Check if input GeoIP databases pointer is valid
+	if geo == nil {
+		return nil, errors.New("GeoIP databases pointer is nil, unable to configure bot")
+	}
+
+	// Assign GeoIP databases to Telegram bot struct
+	bot.geo = geo
+
+	// Check if input prefix sets pointer is valid
+	if prefixSets == nil {
+		return nil, errors.New("prefix sets pointer is nil, unable to configure bot")
+	}
+
+	// Assign prefix sets to Telegram bot struct
+	bot.prefixSets = prefixSets
+
+	// The routing tables are kept until they are cleared or the bot is restarted
+	bot.routes = make(map[int64]*network.RoutingTable)
+	bot.routesMutex = &sync.Mutex{}
+
+	// The RDAP answers are cached in memory
+	bot.rdap = rdap.NewClient(config.RdapServer, config.WhoisServer, config.RdapTimeout*time.Second, config.RdapCacheMinutes*time.Minute)
+	bot.resolver = resolver.NewClient(config.DnsResolver, config.DnsTimeout*time.Second)
+
 	return bot, nil
 }
 
@@ -0,0 +1,195 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package telegram
+
+import (
+	"fmt"
+	"go-Telegram-NetworkCalculator-bot/ipam"
+	"go-Telegram-NetworkCalculator-bot/network"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// textAfterFields returns the text following the first n whitespace separated fields, trimmed
+func textAfterFields(text string, n int) string {
+	text = strings.TrimSpace(text)
+	for i := 0; i < n && text != ""; i++ {
+		end := strings.IndexFunc(text, unicode.IsSpace)
+		if end < 0 {
+			return ""
+		}
+		text = strings.TrimSpace(text[end:])
+	}
+
+	return text
+}
+
+// poolTree formats a pool and its allocations as a tree, ordered by address
+func poolTree(pool ipam.Pool) string {
+	allocated, total := pool.Utilization()
+	percentage := 0.0
+	if total > 0 {
+		percentage = float64(allocated) * 100 / float64(total)
+	}
+
+	allocations := make([]ipam.Allocation, len(pool.Allocations))
+	copy(allocations, pool.Allocations)
+	sort.Slice(allocations, func(a, b int) bool {
+		blockA, _ := network.ParseBlock(allocations[a].Network)
+		blockB, _ := network.ParseBlock(allocations[b].Network)
+		return network.IPToUint32(blockA.Network) < network.IPToUint32(blockB.Network)
+	})
+
+	tree := fmt.Sprintf("%s %s: %d/%d addresses allocated (%.1f%%)", pool.Name, pool.Network, allocated, total, percentage)
+	for i, allocation := range allocations {
+		branch := "├"
+		if i == len(allocations)-1 {
+			branch = "└"
+		}
+
+		tree += "\n" + branch + " " + allocation.Network
+		if allocation.Description != "" {
+			tree += " " + allocation.Description
+		}
+	}
+
+	return tree
+}
+
+// handlePool answers "/pool add <name> <cidr>", "/pool remove <name>", "/pool list" and "/pool <name>"
+func (tg *Telegram) handlePool(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+	if len(args) < 2 {
+		args = append(args, "list")
+	}
+
+	var text string
+	switch strings.ToLower(args[1]) {
+	case "add":
+		if len(args) < 4 {
+			text = "Usage: /pool add <name> <network>/<prefix>"
+			break
+		}
+
+		if err := tg.pools.AddPool(message.Chat.ID, args[2], args[3]); err != nil {
+			text = "ERROR: " + err.Error() + "."
+			break
+		}
+
+		text = "Pool " + args[2] + " added."
+	case "remove", "delete":
+		if len(args) < 3 {
+			text = "Usage: /pool remove <name>"
+			break
+		}
+
+		if err := tg.pools.RemovePool(message.Chat.ID, args[2]); err != nil {
+			text = "ERROR: " + err.Error() + "."
+			break
+		}
+
+		text = "Pool " + args[2] + " removed."
+	case "list":
+		chatPools := tg.pools.GetPools(message.Chat.ID)
+		if len(chatPools) == 0 {
+			text = "There are no pools in this chat, add one with /pool add <name> <network>/<prefix>"
+			break
+		}
+
+		trees := make([]string, 0, len(chatPools))
+		for _, pool := range chatPools {
+			trees = append(trees, poolTree(pool))
+		}
+		text = strings.Join(trees, "\n\n")
+	default:
+		text = "There is no pool named " + args[1]
+		for _, pool := range tg.pools.GetPools(message.Chat.ID) {
+			if strings.EqualFold(pool.Name, args[1]) {
+				text = poolTree(pool)
+			}
+		}
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+}
+
+// handleAlloc answers "/alloc <pool> <prefix> [description]" allocating the next free block of the pool
+func (tg *Telegram) handleAlloc(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+	if len(args) < 3 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /alloc <pool> <prefix> [description]")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	prefix, err := strconv.ParseUint(strings.TrimPrefix(args[2], "/"), 10, 8)
+	if err != nil || prefix > 32 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Invalid prefix: "+args[2])
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	// The description is everything after the prefix, quotes are optional
+	var description string
+	if len(args) > 3 {
+		description = strings.Trim(textAfterFields(message.Text, 3), "\"“”")
+	}
+
+	allocation, err := tg.pools.Allocate(message.Chat.ID, args[1], uint8(prefix), description, int64(message.From.ID))
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "ERROR: "+err.Error()+".")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	text := "Allocated " + allocation.Network + " from " + args[1]
+	if description != "" {
+		text += " for " + description
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+}
+
+// handleRelease answers "/release <pool> <cidr>" releasing an allocated block
+func (tg *Telegram) handleRelease(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+	if len(args) < 3 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /release <pool> <network>/<prefix>")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	text := "Released " + args[2] + " from " + args[1] + "."
+	if err := tg.pools.Release(message.Chat.ID, args[1], args[2]); err != nil {
+		text = "ERROR: " + err.Error() + "."
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+}
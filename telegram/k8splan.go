@@ -0,0 +1,147 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package telegram
+
+import (
+	"fmt"
+	"go-Telegram-NetworkCalculator-bot/network"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Network the cluster networks are allocated from when the user doesn't choose one
+const defaultKubernetesBase = "10.0.0.0/8"
+
+// handleK8sPlan answers "/k8splan <nodes> <pods per node> <services> <cni> [from <cidr>] [avoid <cidr>]" with the cluster networks
+func (tg *Telegram) handleK8sPlan(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+	if len(args) < 5 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /k8splan <nodes> <max pods per node> <services> <kubenet|calico|awsvpc|cilium> [from <network>/<prefix>] [avoid <corporate supernet>]")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	// Parse the quantities
+	var quantities [3]uint64
+	for i := range quantities {
+		quantity, err := strconv.ParseUint(args[i+1], 10, 64)
+		if err != nil {
+			msg := tgbotapi.NewMessage(message.Chat.ID, "Invalid quantity: "+args[i+1])
+			msg.ReplyToMessageID = message.MessageID
+			_, _ = tg.api.Send(msg)
+			return
+		}
+		quantities[i] = quantity
+	}
+
+	cni, found := network.FindCni(args[4])
+	if !found {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Unknown network plugin, use one of: kubenet, calico, awsvpc, cilium")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	// Parse the optional parameters
+	base := defaultKubernetesBase
+	var supernet string
+	for i := 5; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			msg := tgbotapi.NewMessage(message.Chat.ID, "Missing network after \""+args[i]+"\"")
+			msg.ReplyToMessageID = message.MessageID
+			_, _ = tg.api.Send(msg)
+			return
+		}
+
+		switch strings.ToLower(args[i]) {
+		case "from":
+			base = args[i+1]
+		case "avoid":
+			supernet = args[i+1]
+		default:
+			msg := tgbotapi.NewMessage(message.Chat.ID, "Unknown parameter: "+args[i])
+			msg.ReplyToMessageID = message.MessageID
+			_, _ = tg.api.Send(msg)
+			return
+		}
+	}
+
+	plan, err := network.PlanKubernetes(quantities[0], quantities[1], quantities[2], cni, base, supernet)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "ERROR: "+err.Error()+".")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	// Summary of the networks
+	text := fmt.Sprintf("Network plugin: %s\nNodes: %s\nServices: %s\n", plan.Cni, plan.Nodes, plan.Services)
+	if plan.Cni == network.AwsVpc {
+		text += "Pods: addresses taken from the nodes network\n"
+	} else {
+		text += fmt.Sprintf("Pods: %s (%d x /%d per node)\n", plan.Pods, plan.BlocksPerNode, plan.PodPrefix)
+	}
+
+	if len(plan.Conflicts) > 0 {
+		text += "\n⚠️ Conflicts:\n" + strings.Join(plan.Conflicts, "\n") + "\n"
+	} else if supernet != "" {
+		text += "\nNo conflicts with " + supernet + "\n"
+	}
+
+	text = escapeMarkdown(text)
+	text += "\n*kubeadm*\n" + markdownCodeBlock("yaml", kubeadmSnippet(plan))
+	if values := helmValuesSnippet(plan); values != "" {
+		text += "\n*Helm values*\n" + markdownCodeBlock("yaml", values)
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ParseMode = tgbotapi.ModeMarkdownV2
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+}
+
+// kubeadmSnippet generates the networking part of a kubeadm ClusterConfiguration
+func kubeadmSnippet(plan network.KubernetesPlan) string {
+	snippet := "apiVersion: kubeadm.k8s.io/v1beta3\nkind: ClusterConfiguration\nnetworking:\n"
+	if plan.Cni != network.AwsVpc {
+		snippet += "  podSubnet: " + plan.Pods.String() + "\n"
+	}
+	snippet += "  serviceSubnet: " + plan.Services.String()
+
+	// Only kubenet relies on the controller manager to assign the pods networks to the nodes
+	if plan.Cni == network.Kubenet {
+		snippet += fmt.Sprintf("\ncontrollerManager:\n  extraArgs:\n    allocate-node-cidrs: \"true\"\n    node-cidr-mask-size: \"%d\"", plan.PodPrefix)
+	}
+
+	return snippet
+}
+
+// helmValuesSnippet generates the Helm chart values of the network plugin, empty if it isn't installed with Helm
+func helmValuesSnippet(plan network.KubernetesPlan) string {
+	switch plan.Cni {
+	case network.Calico:
+		return fmt.Sprintf("# tigera-operator chart\ninstallation:\n  calicoNetwork:\n    ipPools:\n      - cidr: %s\n        blockSize: %d\n        encapsulation: VXLANCrossSubnet\n        natOutgoing: Enabled", plan.Pods, plan.PodPrefix)
+	case network.Cilium:
+		return fmt.Sprintf("# cilium chart\nipam:\n  mode: cluster-pool\n  operator:\n    clusterPoolIPv4PodCIDRList:\n      - %s\n    clusterPoolIPv4MaskSize: %d", plan.Pods, plan.PodPrefix)
+	case network.AwsVpc:
+		return fmt.Sprintf("# aws-vpc-cni chart, nodes subnet: %s\nenv:\n  WARM_ENI_TARGET: \"1\"\n  AWS_VPC_K8S_CNI_EXTERNALSNAT: \"false\"", plan.Nodes)
+	}
+
+	return ""
+}
@@ -0,0 +1,37 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package telegram
+
+import "strings"
+
+// Characters that must be escaped in MarkdownV2 text outside of code entities
+var markdownEscaper = strings.NewReplacer(
+	"\\", "\\\\", "_", "\\_", "*", "\\*", "[", "\\[", "]", "\\]", "(", "\\(", ")", "\\)", "~", "\\~", "`", "\\`",
+	">", "\\>", "#", "\\#", "+", "\\+", "-", "\\-", "=", "\\=", "|", "\\|", "{", "\\{", "}", "\\}", ".", "\\.", "!", "\\!",
+)
+
+// Characters that must be escaped in MarkdownV2 pre and code entities
+var codeEscaper = strings.NewReplacer("\\", "\\\\", "`", "\\`")
+
+// escapeMarkdown escapes a text to be sent as plain MarkdownV2 text
+func escapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+// markdownCodeBlock formats a text as a MarkdownV2 pre-formatted block with the given language (empty for none)
+func markdownCodeBlock(language string, text string) string {
+	return "```" + language + "\n" + codeEscaper.Replace(text) + "\n```"
+}
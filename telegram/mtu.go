@@ -0,0 +1,89 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package telegram
+
+import (
+	"fmt"
+	"go-Telegram-NetworkCalculator-bot/network"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Size of the small packets whose efficiency is shown by /mtu, like the ones of VoIP
+const smallPacketSize = 100
+
+// handleMtu answers "/mtu [base MTU] <encapsulations>" with the inner MTU and TCP MSS left by a stack of
+// encapsulations, written from the outermost
+func (tg *Telegram) handleMtu(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)[1:]
+
+	base := 1500
+	if len(args) > 0 {
+		if value, err := strconv.Atoi(args[0]); err == nil {
+			base, args = value, args[1:]
+		}
+	}
+
+	if len(args) == 0 || base < 68 || base > 65535 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /mtu [base MTU] <encapsulations>\nThe encapsulations go from the outermost, like /mtu 1500 pppoe esp:aes-cbc-sha256 gre. The tunnels and ESP have an outer IPv4 header, add :ipv6 for IPv6.\nEncapsulations: "+strings.Join(network.EncapsulationNames(), ", "))
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	stack := make([]network.Encapsulation, 0, len(args))
+	lines := []string{fmt.Sprintf("Base MTU: %d", base)}
+	mtu := base
+	for _, arg := range args {
+		encapsulation, err := network.ParseEncapsulation(arg)
+		if err != nil {
+			msg := tgbotapi.NewMessage(message.Chat.ID, "ERROR: "+err.Error()+".")
+			msg.ReplyToMessageID = message.MessageID
+			_, _ = tg.api.Send(msg)
+			return
+		}
+		stack = append(stack, encapsulation)
+
+		inner := network.InnerMTU(base, stack)
+		lines = append(lines, fmt.Sprintf("%s: -%d bytes, MTU %d", encapsulation.Name, mtu-inner, inner))
+		mtu = inner
+	}
+
+	if mtu < 68 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, strings.Join(lines, "\n")+"\n\nERROR: the encapsulations leave no room for an IP packet.")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	wire, smallWire := network.WireSize(stack, mtu), network.WireSize(stack, smallPacketSize)
+	lines = append(lines,
+		"",
+		fmt.Sprintf("Inner MTU: %d", mtu),
+		fmt.Sprintf("TCP MSS: %d (IPv4), %d (IPv6)", network.TCPMSS(mtu, false), network.TCPMSS(mtu, true)),
+		fmt.Sprintf("Full-size packets: %d bytes of overhead, efficiency %.1f%% (%.1f%% TCP payload over IPv4)", wire-mtu, float64(mtu)*100/float64(wire), float64(network.TCPMSS(mtu, false))*100/float64(wire)),
+		fmt.Sprintf("%d bytes packets: %d bytes of overhead, efficiency %.1f%%", smallPacketSize, smallWire-smallPacketSize, float64(smallPacketSize)*100/float64(smallWire)),
+	)
+	if mtu < 1280 {
+		lines = append(lines, "WARNING: IPv6 needs an MTU of at least 1280, the inner IPv6 packets must be fragmented by the tunnel.")
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, strings.Join(lines, "\n"))
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+}
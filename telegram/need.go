@@ -0,0 +1,101 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package telegram
+
+import (
+	"fmt"
+	"go-Telegram-NetworkCalculator-bot/network"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// handleNeed answers "/need <hosts> [hosts] [within <cidr>] [cloud [provider]]" with the smallest network that fits
+func (tg *Telegram) handleNeed(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+	if len(args) < 2 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /need <hosts> hosts [within <network>/<prefix>] [cloud [aws|azure|gcp]]")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	hosts, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Invalid hosts quantity: "+args[1])
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	// Parse the optional parameters
+	var within string
+	provider := network.Standard
+	for i := 2; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "host", "hosts":
+			continue
+		case "within", "in":
+			if i+1 >= len(args) {
+				msg := tgbotapi.NewMessage(message.Chat.ID, "Missing network after \""+args[i]+"\"")
+				msg.ReplyToMessageID = message.MessageID
+				_, _ = tg.api.Send(msg)
+				return
+			}
+			i++
+			within = args[i]
+		case "cloud":
+			provider = network.Cloud
+			if i+1 < len(args) {
+				if cloudProvider, found := network.FindProvider(args[i+1]); found {
+					provider = cloudProvider
+					i++
+				}
+			}
+		default:
+			if cloudProvider, found := network.FindProvider(args[i]); found {
+				provider = cloudProvider
+				continue
+			}
+
+			msg := tgbotapi.NewMessage(message.Chat.ID, "Unknown parameter: "+args[i])
+			msg.ReplyToMessageID = message.MessageID
+			_, _ = tg.api.Send(msg)
+			return
+		}
+	}
+
+	plan, err := network.PlanHosts(hosts, provider, within)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "ERROR: "+err.Error()+".")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	text := fmt.Sprintf("Hosts needed: %d (%s: %d reserved per subnet)\nPrefix: /%d\nNetmask: %s\nWildcard: %s\nBlock size: %d addresses (%d usable)",
+		plan.Hosts, plan.Provider.Name, plan.Provider.Reserved(), plan.Netmask.Decimal, network.ByteArrToStr(plan.Netmask.Dotted), network.ByteArrToStr(plan.Wildcard), plan.BlockSize, plan.Usable)
+
+	if plan.FirstBlock != nil {
+		text += fmt.Sprintf("\nFirst block: %s/%d\nBlocks in %s/%d: %d",
+			network.ByteArrToStr(plan.FirstBlock), plan.Netmask.Decimal, network.ByteArrToStr(plan.Within), plan.WithinMask.Decimal, plan.Blocks)
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+}
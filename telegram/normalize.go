@@ -0,0 +1,98 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package telegram
+
+import (
+	"fmt"
+	"go-Telegram-NetworkCalculator-bot/network"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// handleNormalize answers "/normalize [collapse] <list>" with the addresses and prefixes of a messy list sorted,
+// masked to their network and without duplicates. The list can also be in the replied-to message or in a document
+func (tg *Telegram) handleNormalize(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+	collapse := len(args) >= 2 && strings.ToLower(args[1]) == "collapse"
+
+	// Skip the command and the option, the rest is the list
+	content, err := tg.messageContent(message)
+	if collapse {
+		content = textAfterFields(content, 2)
+	} else {
+		content = textAfterFields(content, 1)
+	}
+	if err == nil && message.ReplyToMessage != nil {
+		var replyContent string
+		replyContent, err = tg.messageContent(message.ReplyToMessage)
+		content += "\n" + replyContent
+	}
+
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "ERROR: "+err.Error()+".")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	list := network.NormalizeList(content)
+	if len(list.Prefixes) == 0 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /normalize [collapse] <addresses, prefixes or ranges>\nAny separator and comments (#, //, !) are accepted, the list can also be in the message you reply to or in a document.")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	prefixes := list.Prefixes
+	if collapse {
+		prefixes = network.CollapsePrefixes(prefixes)
+	}
+
+	lines := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		lines = append(lines, network.PrefixString(prefix))
+	}
+
+	text := fmt.Sprintf("Entries: %d\nDuplicates removed: %d\nPrefixes with host bits set: %d", len(prefixes), list.Duplicates, list.Masked)
+	if collapse {
+		text += fmt.Sprintf("\nCollapsed from: %d", len(list.Prefixes))
+	}
+	if len(list.Invalid) > 0 {
+		ignored := list.Invalid
+		if len(ignored) > maxPlanLines {
+			ignored = append(ignored[:maxPlanLines:maxPlanLines], "…")
+		}
+		text += fmt.Sprintf("\nIgnored (%d): %s", len(list.Invalid), strings.Join(ignored, " "))
+	}
+
+	// Long lists are sent as document
+	if len(lines) > maxPlanLines {
+		msg := tgbotapi.NewMessage(message.Chat.ID, text)
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+
+		document := tgbotapi.NewDocumentUpload(message.Chat.ID, tgbotapi.FileBytes{Name: "normalized.txt", Bytes: []byte(strings.Join(lines, "\n") + "\n")})
+		document.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(document)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, escapeMarkdown(text+"\n")+markdownCodeBlock("", strings.Join(lines, "\n")))
+	msg.ParseMode = tgbotapi.ModeMarkdownV2
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+}
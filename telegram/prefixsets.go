@@ -0,0 +1,153 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package telegram
+
+import (
+	"fmt"
+	"go-Telegram-NetworkCalculator-bot/config"
+	"go-Telegram-NetworkCalculator-bot/prefixsets"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// handleSetLoad answers "/setload <name> [file]" creating or replacing a prefix set from a file of
+// config.PrefixSetsDir, or from the document of the message or of the replied-to message
+func (tg *Telegram) handleSetLoad(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+
+	document := message.Document
+	if document == nil && message.ReplyToMessage != nil {
+		document = message.ReplyToMessage.Document
+	}
+
+	if len(args) < 2 || (len(args) < 3 && document == nil) {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /setload <name> [file]\nThe prefixes are read from a file of the "+config.PrefixSetsDir+" directory, or from the document sent with the command or replied to. AWS, Google, Azure and Cloudflare JSON ranges and text lists (like the Team Cymru bogons) are supported.")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	var content []byte
+	var source string
+	var err error
+	if len(args) >= 3 {
+		// Only the files of the directory can be loaded, never a path
+		source = args[2]
+		if filepath.Base(source) != source || source == ".." {
+			err = fmt.Errorf("%s isn't a file name of the %s directory", source, config.PrefixSetsDir)
+		} else {
+			content, err = ioutil.ReadFile(filepath.Join(config.PrefixSetsDir, source))
+		}
+	} else {
+		source = document.FileName
+		content, err = tg.downloadDocument(document)
+	}
+
+	var format string
+	var entries []prefixsets.Entry
+	if err == nil {
+		format, entries, err = prefixsets.Parse(content)
+	}
+	if err == nil {
+		err = tg.prefixSets.Replace(strings.ToLower(args[1]), format, source, entries)
+	}
+
+	text := fmt.Sprintf("Set %s loaded from %s (%s): %d prefixes.", strings.ToLower(args[1]), source, format, len(entries))
+	if err != nil {
+		text = "ERROR: " + err.Error() + "."
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+}
+
+// handleSetDelete answers "/setdel <name>" removing a prefix set
+func (tg *Telegram) handleSetDelete(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+
+	text := "Usage: /setdel <name>"
+	if len(args) >= 2 {
+		text = "Set " + strings.ToLower(args[1]) + " removed."
+		if err := tg.prefixSets.Remove(strings.ToLower(args[1])); err != nil {
+			text = "ERROR: " + err.Error() + "."
+		}
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+}
+
+// handleSets answers "/sets" with the loaded prefix sets
+func (tg *Telegram) handleSets(message *tgbotapi.Message) {
+	sets := tg.prefixSets.List()
+
+	lines := make([]string, 0, len(sets))
+	for _, set := range sets {
+		lines = append(lines, fmt.Sprintf("%s: %d prefixes, %s from %s, updated %s UTC", set.Name, len(set.Entries), set.Format, set.Source, set.Updated.UTC().Format("2006-01-02 15:04")))
+	}
+
+	text := "No prefix set is loaded."
+	if len(lines) > 0 {
+		text = "Prefix sets:\n" + strings.Join(lines, "\n") + "\n\nCheck an address with /inset <address>."
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+}
+
+// handleInSet answers "/inset <ip>" with every prefix set, and its service or region, containing an address
+func (tg *Telegram) handleInSet(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+	if len(args) < 2 || net.ParseIP(args[1]) == nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /inset <address>\nThe loaded sets are listed by /sets.")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	address := net.ParseIP(args[1])
+	matches := tg.prefixSets.Lookup(address)
+
+	lines := make([]string, 0, len(matches))
+	for i, match := range matches {
+		if i == maxPlanLines {
+			lines = append(lines, fmt.Sprintf("… and %d more", len(matches)-maxPlanLines))
+			break
+		}
+
+		lines = append(lines, strings.TrimSpace(match.Set+": "+match.Prefix+" "+match.Tag))
+	}
+	names := make(map[string]bool)
+	for _, match := range matches {
+		names[match.Set] = true
+	}
+
+	text := address.String() + " isn't in any prefix set."
+	if len(matches) > 0 {
+		text = fmt.Sprintf("%s is in %d of the prefix sets:\n%s", address, len(names), strings.Join(lines, "\n"))
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+}
@@ -0,0 +1,114 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package telegram
+
+import (
+	"fmt"
+	"go-Telegram-NetworkCalculator-bot/network"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Maximum quantity of addresses picked by /random
+const maxRandomHosts = 100
+
+// handleULA answers "/ula [mac] [timestamp]" with a RFC 4193 Unique Local prefix
+func (tg *Telegram) handleULA(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+
+	// The seeds can be given in any order, the timestamp as RFC 3339 or Unix time
+	var mac net.HardwareAddr
+	timestamp := time.Now()
+	for _, arg := range args[1:] {
+		if parsedMac, err := net.ParseMAC(arg); err == nil {
+			mac = parsedMac
+		} else if parsedTime, err := time.Parse(time.RFC3339, arg); err == nil {
+			timestamp = parsedTime
+		} else if unixTime, err := strconv.ParseInt(arg, 10, 64); err == nil {
+			timestamp = time.Unix(unixTime, 0)
+		} else {
+			msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /ula [MAC address] [timestamp as RFC 3339 or Unix time]")
+			msg.ReplyToMessageID = message.MessageID
+			_, _ = tg.api.Send(msg)
+			return
+		}
+	}
+
+	prefix, err := network.ULAPrefix(mac, timestamp)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "ERROR: "+err.Error()+".")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	firstSubnet, _ := prefix.Subnet(64, 0)
+	text := fmt.Sprintf("ULA prefix: %s\nGlobal ID: %x\nFirst /64: %s\nSubnets: 65536 /64", prefix, []byte(prefix.Network[1:6]), firstSubnet)
+	if mac == nil {
+		text += "\nSeed: random EUI-64"
+	} else {
+		text += "\nSeed: " + mac.String()
+	}
+	text += "\nTimestamp: " + timestamp.UTC().Format(time.RFC3339)
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+}
+
+// handleRandom answers "/random <prefix> [n]" with n random usable addresses of the network
+func (tg *Telegram) handleRandom(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+	if len(args) < 2 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /random <network>/<prefix> [quantity]")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	quantity := 1
+	if len(args) >= 3 {
+		var err error
+		quantity, err = strconv.Atoi(args[2])
+		if err != nil || quantity < 1 || quantity > maxRandomHosts {
+			msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("The quantity must be between 1 and %d", maxRandomHosts))
+			msg.ReplyToMessageID = message.MessageID
+			_, _ = tg.api.Send(msg)
+			return
+		}
+	}
+
+	hosts, err := network.RandomHosts(args[1], quantity)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "ERROR: "+err.Error()+".")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	lines := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		lines = append(lines, host.String())
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, strings.Join(lines, "\n"))
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+}
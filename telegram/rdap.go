@@ -0,0 +1,86 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package telegram
+
+import (
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Telegram refuses messages longer than 4096 characters, the WHOIS answers are cut before
+const maxWhoisLength = 3500
+
+// handleRdap answers "/rdap <ip|asn|domain>" with the registration of an address block, an autonomous system or a
+// domain, from the RDAP server or from the WHOIS one
+func (tg *Telegram) handleRdap(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+	if len(args) < 2 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /rdap <address|prefix|AS number|domain>")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	result, err := tg.rdap.Query(args[1])
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "ERROR: "+err.Error()+".")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	lines := make([]string, 0)
+	if result.Whois != "" {
+		// Only the data of the WHOIS answer, without the comments and the empty lines
+		length := 0
+		for _, line := range strings.Split(result.Whois, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "%") || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if len(lines) == maxPlanLines || length+len(line) > maxWhoisLength {
+				lines = append(lines, "…")
+				break
+			}
+			lines = append(lines, line)
+			length += len(line) + 1
+		}
+	} else {
+		for _, field := range [][2]string{
+			{"Handle", result.Handle},
+			{"Name", result.Name},
+			{"Holder", result.Holder},
+			{"Block", result.Block},
+			{"Type", result.Type},
+			{"Country", result.Country},
+			{"Status", strings.Join(result.Status, ", ")},
+			{"Abuse", strings.Join(result.Abuse, "\n       ")},
+			{"Registered", result.Registered},
+			{"Updated", result.Updated},
+			{"Expires", result.Expires},
+		} {
+			if field[1] != "" {
+				lines = append(lines, field[0]+": "+field[1])
+			}
+		}
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, "Registration of "+args[1]+" ("+result.Kind+")\n\n"+strings.Join(lines, "\n")+"\n\nSource: "+result.Source)
+	msg.ReplyToMessageID = message.MessageID
+	msg.DisableWebPagePreview = true
+	_, _ = tg.api.Send(msg)
+}
@@ -0,0 +1,88 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package telegram
+
+import (
+	"go-Telegram-NetworkCalculator-bot/network"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Order of the dialects shown by /regex when none is requested
+var regexDialectNames = []string{"pcre", "re2", "grep"}
+
+// handleRegex answers "/regex <cidr|first-last> [pcre|re2|grep] [word]" with the regular expressions matching exactly
+// the addresses of a prefix or of a range
+func (tg *Telegram) handleRegex(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+	if len(args) < 2 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /regex <network>/<prefix> or <first>-<last> [pcre|re2|grep] [word]\nWith word the expression finds the addresses inside a text, like a log line, otherwise it matches a whole field.")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	// The addresses are a prefix or a range
+	var addresses network.AddressRange
+	block, err := network.ParseBlock(args[1])
+	if err == nil {
+		addresses = network.AddressRange{First: block.Network, Last: network.Uint32ToIP(network.IPToUint32(block.Network) + uint32(block.Size()-1))}
+	} else {
+		addresses, err = network.ParseRange(args[1])
+	}
+
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "ERROR: "+err.Error()+". Only IPv4 prefixes and ranges are supported.")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	names := make([]string, 0, len(regexDialectNames))
+	word := false
+	for _, arg := range args[2:] {
+		if strings.ToLower(arg) == "word" {
+			word = true
+		} else if _, found := network.FindRegexDialect(arg); found {
+			names = append(names, arg)
+		} else {
+			msg := tgbotapi.NewMessage(message.Chat.ID, "Unknown option "+arg+", use pcre, re2, grep or word")
+			msg.ReplyToMessageID = message.MessageID
+			_, _ = tg.api.Send(msg)
+			return
+		}
+	}
+	if len(names) == 0 {
+		names = regexDialectNames
+	}
+
+	text := escapeMarkdown("Addresses: " + addresses.String() + "\n")
+	consuming := false
+	for _, name := range names {
+		dialect, _ := network.FindRegexDialect(name)
+		text += "\n" + escapeMarkdown(dialect.Name+":") + "\n" + markdownCodeBlock("", dialect.AddressRegex(addresses.First, addresses.Last, word))
+		consuming = consuming || !strings.HasPrefix(dialect.WordStart, "(?<")
+	}
+	if word && consuming {
+		text += "\n" + escapeMarkdown("RE2 and grep -E have no lookarounds, so their matches include the character before and after the address.")
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ParseMode = tgbotapi.ModeMarkdownV2
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+}
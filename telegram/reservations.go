@@ -0,0 +1,230 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package telegram
+
+import (
+	"fmt"
+	"go-Telegram-NetworkCalculator-bot/config"
+	"go-Telegram-NetworkCalculator-bot/ipam"
+	"go-Telegram-NetworkCalculator-bot/network"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Format of the dates shown and accepted by the reservations commands
+const reservationDateFormat = "2006-01-02"
+
+// ManageReservations starts a goroutine that warns the owners of the expiring reservations and releases the expired ones
+func (tg *Telegram) ManageReservations() {
+	go func() {
+		ticker := time.NewTicker(config.ReservationsCheckMinutes * time.Minute)
+		defer ticker.Stop()
+
+		for now := range ticker.C {
+			tg.checkReservations(now)
+		}
+	}()
+}
+
+// checkReservations sends the expiry notices and releases the expired reservations
+func (tg *Telegram) checkReservations(now time.Time) {
+	expiring, err := tg.reservations.Expiring(now.Add(config.ReservationsNoticeHours * time.Hour))
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	for _, reservation := range expiring {
+		msg := tgbotapi.NewMessage(reservation.Owner, fmt.Sprintf("⏰ Your reservation of %s (%s) expires on %s UTC. Send /reserve %s <days or YYYY-MM-DD> <purpose> again in the same chat to extend it.", reservation.Address, reservation.Purpose, reservation.Expiry.UTC().Format("2006-01-02 15:04"), reservation.Address))
+		_, _ = tg.api.Send(msg)
+	}
+
+	expired, err := tg.reservations.ReleaseExpired(now)
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	for _, reservation := range expired {
+		text := fmt.Sprintf("The reservation of %s (%s) by %s has expired, the address is free again.", reservation.Address, reservation.Purpose, reservation.OwnerName)
+		_, _ = tg.api.Send(tgbotapi.NewMessage(reservation.Owner, text))
+		if reservation.ChatID != reservation.Owner {
+			_, _ = tg.api.Send(tgbotapi.NewMessage(reservation.ChatID, text))
+		}
+	}
+}
+
+// parseExpiry parses a quantity of days ("30" or "30d") or a date
+func parseExpiry(str string) (time.Time, error) {
+	if days, err := strconv.Atoi(strings.TrimSuffix(strings.ToLower(str), "d")); err == nil && days > 0 {
+		return time.Now().AddDate(0, 0, days), nil
+	}
+
+	date, err := time.Parse(reservationDateFormat, str)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid expiry %s, use a quantity of days or a date as YYYY-MM-DD", str)
+	}
+
+	return date, nil
+}
+
+// handleReserve answers "/reserve <ip> <days|date> <purpose>" reserving an address of a pool for the user, or extending
+// the reservation if the user already owns it
+func (tg *Telegram) handleReserve(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+	if len(args) < 4 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /reserve <address> <days or YYYY-MM-DD> <purpose>")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	// Only the addresses of the registered pools can be reserved
+	address := net.ParseIP(args[1])
+	if _, _, found := tg.pools.Lookup(message.Chat.ID, address); !found {
+		msg := tgbotapi.NewMessage(message.Chat.ID, args[1]+" isn't in any pool of this chat, add one with /pool add <name> <network>/<prefix>")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	expiry, err := parseExpiry(args[2])
+	if err == nil {
+		err = tg.reservations.Reserve(ipam.Reservation{
+			ChatID:    message.Chat.ID,
+			Address:   args[1],
+			Owner:     int64(message.From.ID),
+			OwnerName: message.From.FirstName,
+			Purpose:   textAfterFields(message.Text, 3),
+			Expiry:    expiry,
+		})
+	}
+
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "ERROR: "+err.Error()+".")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("%s reserved until %s. You'll get a private message before it expires, if you started the bot.", address, expiry.Format(reservationDateFormat)))
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+}
+
+// handleUnreserve answers "/unreserve <ip>" releasing a reservation of the user (admins can release any reservation)
+func (tg *Telegram) handleUnreserve(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+	if len(args) < 2 || net.ParseIP(args[1]).To4() == nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /unreserve <address>")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	isAdmin := tg.db.FindAdmin(int64(message.From.ID)) >= 0
+	text := args[1] + " is no longer reserved."
+	if err := tg.reservations.Unreserve(message.Chat.ID, net.ParseIP(args[1]).To4().String(), int64(message.From.ID), isAdmin); err != nil {
+		text = "ERROR: " + err.Error() + "."
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+}
+
+// handleWhoHas answers "/whohas <ip>" with the reservation, allocation and pool of an address
+func (tg *Telegram) handleWhoHas(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+	if len(args) < 2 || net.ParseIP(args[1]).To4() == nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /whohas <address>")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	address := net.ParseIP(args[1]).To4()
+	pool, allocation, found := tg.pools.Lookup(message.Chat.ID, address)
+
+	var text string
+	if reservation, reserved := tg.reservations.Find(message.Chat.ID, address.String()); reserved {
+		text = fmt.Sprintf("%s is reserved by %s (ID %d) until %s", address, reservation.OwnerName, reservation.Owner, reservation.Expiry.Format(reservationDateFormat))
+		if reservation.Purpose != "" {
+			text += "\nPurpose: " + reservation.Purpose
+		}
+	} else if found {
+		text = address.String() + " isn't reserved"
+	} else {
+		text = address.String() + " isn't in any pool of this chat"
+	}
+
+	if found {
+		text += "\nPool: " + pool.Name + " " + pool.Network
+	}
+	if allocation != nil {
+		text += "\nAllocation: " + allocation.Network + " " + allocation.Description
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+}
+
+// handleFree answers "/free <cidr>" with the addresses of a network that aren't reserved
+func (tg *Telegram) handleFree(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+	if len(args) < 2 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /free <network>/<prefix>")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	block, err := network.ParseBlock(args[1])
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "ERROR: "+err.Error()+".")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	reserved := tg.reservations.InBlock(message.Chat.ID, block)
+	used := make([][]uint8, 0, len(reserved))
+	for _, reservation := range reserved {
+		used = append(used, net.ParseIP(reservation.Address).To4())
+	}
+
+	ranges, quantity := network.FreeRanges(block, used)
+	text := fmt.Sprintf("%s: %d free addresses, %d reserved", block, quantity, len(reserved))
+	for i, freeRange := range ranges {
+		if i == maxPlanLines {
+			text += "\n…"
+			break
+		}
+
+		if network.IPToUint32(freeRange.First) == network.IPToUint32(freeRange.Last) {
+			text += "\n" + network.ByteArrToStr(freeRange.First)
+		} else {
+			text += "\n" + freeRange.String()
+		}
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+}
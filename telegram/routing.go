@@ -0,0 +1,140 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package telegram
+
+import (
+	"fmt"
+	"go-Telegram-NetworkCalculator-bot/network"
+	"net"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// handleRoutes answers "/routes [clear]" loading the routing table of the chat from the message, the replied-to
+// message or their documents, or clearing it
+func (tg *Telegram) handleRoutes(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+
+	if len(args) >= 2 && strings.ToLower(args[1]) == "clear" {
+		tg.routesMutex.Lock()
+		delete(tg.routes, message.Chat.ID)
+		tg.routesMutex.Unlock()
+
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Routing table cleared.")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	// Skip the command, the rest is the routing table
+	content, err := tg.messageContent(message)
+	content = textAfterFields(content, 1)
+	if err == nil && message.ReplyToMessage != nil {
+		var replyContent string
+		replyContent, err = tg.messageContent(message.ReplyToMessage)
+		content += "\n" + replyContent
+	}
+
+	if err == nil && strings.TrimSpace(content) == "" {
+		text := "Usage: /routes <routing table> or /routes clear\nThe table can be plain \"prefix nexthop\" lines or the output of \"ip route\", \"show ip route\" (Cisco) or \"show route\" (Junos), pasted, sent as document or in the message you reply to."
+
+		tg.routesMutex.Lock()
+		if table, found := tg.routes[message.Chat.ID]; found {
+			text = fmt.Sprintf("The routing table of this chat has %d routes, look up a destination with /lookup <address>.\n\n%s", table.Len(), text)
+		}
+		tg.routesMutex.Unlock()
+
+		msg := tgbotapi.NewMessage(message.Chat.ID, text)
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	var table *network.RoutingTable
+	if err == nil {
+		table, err = network.ParseRoutingTable(content)
+	}
+
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "ERROR: "+err.Error()+".")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	tg.routesMutex.Lock()
+	tg.routes[message.Chat.ID] = table
+	tg.routesMutex.Unlock()
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Routing table loaded: %d routes. Look up a destination with /lookup <address>.", table.Len()))
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+}
+
+// handleLookup answers "/lookup <ip>" with the longest prefix match of the address in the routing table of the chat
+func (tg *Telegram) handleLookup(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+	if len(args) < 2 || net.ParseIP(args[1]) == nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /lookup <address>")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	tg.routesMutex.Lock()
+	table, found := tg.routes[message.Chat.ID]
+	tg.routesMutex.Unlock()
+
+	if !found {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "There is no routing table in this chat, load one with /routes")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	address := net.ParseIP(args[1])
+	matches := table.Lookup(address)
+
+	var text string
+	if len(matches) == 0 {
+		text = fmt.Sprintf("❌ %s is unreachable: no route matches and there is no default route.", address)
+	} else {
+		best := matches[len(matches)-1]
+		if best.Discard {
+			text = fmt.Sprintf("❌ %s is unreachable: the packets are dropped by %s", address, best.Describe())
+		} else {
+			text = fmt.Sprintf("✅ %s matches %s", address, best.Describe())
+			if len(best.NextHops) == 0 {
+				text += "\nNext hop: the destination itself (directly connected)"
+			}
+			for _, nextHop := range best.NextHops {
+				text += "\nNext hop: " + nextHop.String()
+			}
+		}
+
+		// Every matching prefix, from the least to the most specific one
+		text += "\n\nLookup path:"
+		for _, route := range matches {
+			text += "\n" + route.Prefix.String()
+		}
+		text += " ← longest match"
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+}
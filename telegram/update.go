@@ -17,8 +17,8 @@ package telegram
 
 import (
 	"fmt"
-	"go-Telegram-NetworkCalculator-Bot/network"
 	"go-Telegram-NetworkCalculator-bot/config"
+	"go-Telegram-NetworkCalculator-bot/network"
 	"net"
 	"strconv"
 	"strings"
@@ -52,30 +52,26 @@ import (
 // In case of ambiguity between italic and underline entities __ is always greadily treated from left to right as beginning or end of underline entity, so instead of ___italic underline___ use ___italic underline_\r__, where \r is a character with code 13, which will be ignored.
 
 func (tg *Telegram) HandleUpdate(update tgbotapi.Update) {
-	// Check for ban and inform the user only on private chat to avoid flood
-	if tg.db.FindBan(int64(update.Message.From.ID)) >= 0 {
-		if update.Message.Chat.Type == "private" {
-			msg := tgbotapi.NewMessage(update.Message.Chat.ID, "🚫 You have been banned from this bot!")
-			msg.ReplyToMessageID = update.Message.MessageID
-			tg.api.Send(msg)
+	// Handle the inline buttons, their updates have no message
+	if update.CallbackQuery != nil {
+		if tg.db.FindBan(int64(update.CallbackQuery.From.ID)) >= 0 {
+			_, _ = tg.api.AnswerCallbackQuery(tgbotapi.NewCallback(update.CallbackQuery.ID, "🚫 You have been banned from this bot!"))
+			return
 		}
 
-		return
-	}
-
-	// Skip messages from channels
-	if update.Message.Chat.Type == "channel" {
-		return
-	}
-
-	if update.CallbackQuery != nil {
 		text := strings.Split(update.CallbackQuery.Data, " ")
 		switch text[0] {
 		case "first":
 			break
 		case "file":
 			break
+		case "extract":
+			tg.handleExtractCallback(update.CallbackQuery, text[1:])
+		case "calc":
+			tg.handleCalcCallback(update.CallbackQuery, text[1:])
 		}
+
+		return
 	}
 
 	// Skip if there isn't a real update
@@ -88,6 +84,27 @@ func (tg *Telegram) HandleUpdate(update tgbotapi.Update) {
 		update.Message = update.EditedMessage
 	}
 
+	// Check for ban and inform the user only on private chat to avoid flood
+	if tg.db.FindBan(int64(update.Message.From.ID)) >= 0 {
+		if update.Message.Chat.Type == "private" {
+			msg := tgbotapi.NewMessage(update.Message.Chat.ID, "🚫 You have been banned from this bot!")
+			msg.ReplyToMessageID = update.Message.MessageID
+			tg.api.Send(msg)
+		}
+
+		return
+	}
+
+	// Skip messages from channels
+	if update.Message.Chat.Type == "channel" {
+		return
+	}
+
+	// The commands can also be sent as caption of a document
+	if update.Message.Text == "" && update.Message.Document != nil {
+		update.Message.Text = update.Message.Caption
+	}
+
 	// Commands for admins only
 	if tg.db.FindAdmin(int64(update.Message.From.ID)) >= 0 {
 		if update.Message.Text == "/ping" {
@@ -98,12 +115,22 @@ func (tg *Telegram) HandleUpdate(update tgbotapi.Update) {
 		}
 
 		if len(update.Message.Text) >= 5 && strings.ToLower(update.Message.Text[1:5]) == "help" && update.Message.Chat.Type == "private" {
-			msg := tgbotapi.NewMessage(update.Message.Chat.ID, "*HELP*\n\n*/ping* \\- send a test message\\.\n*/admin* \\- When you reply to a person message, he will become an admin\\.\n*/unadmin* \\- remove an admin\\.\n*/ban* \\- ban a person from the bot\\.\n*/unban* \\- unban a person from the bot\\.\n*/comestero <known key\\> <known key sector \\(0\\-15\\)\\> <known key type \\(A/B\\)\\>* \\- generate keys for a comestero vending key\\.")
+			msg := tgbotapi.NewMessage(update.Message.Chat.ID, "*HELP*\n\n*/ping* \\- send a test message\\.\n*/admin* \\- When you reply to a person message, he will become an admin\\.\n*/unadmin* \\- remove an admin\\.\n*/ban* \\- ban a person from the bot\\.\n*/unban* \\- unban a person from the bot\\.\n*/comestero <known key\\> <known key sector \\(0\\-15\\)\\> <known key type \\(A/B\\)\\>* \\- generate keys for a comestero vending key\\.\n*/georeload* \\- reload the GeoIP and ASN databases\\.\n*/setload <name\\> \\[file\\]* \\- load a prefix set from a file or a document\\.\n*/setdel <name\\>* \\- remove a prefix set\\.")
 			msg.ParseMode = tgbotapi.ModeMarkdownV2
 			_, _ = tg.api.Send(msg)
 			return
 		}
 
+		if len(update.Message.Text) >= 8 && strings.ToLower(update.Message.Text[0:8]) == "/setload" {
+			tg.handleSetLoad(update.Message)
+			return
+		}
+
+		if len(update.Message.Text) >= 7 && strings.ToLower(update.Message.Text[0:7]) == "/setdel" {
+			tg.handleSetDelete(update.Message)
+			return
+		}
+
 		if update.Message.Text == "/admin" {
 			// Check if the user is replying to a message
 			if update.Message.ReplyToMessage != nil {
@@ -232,6 +259,225 @@ func (tg *Telegram) HandleUpdate(update tgbotapi.Update) {
 		return
 	}
 
+	// Find the smallest network able to contain the requested hosts
+	if len(update.Message.Text) >= 5 && strings.ToLower(update.Message.Text[0:5]) == "/need" {
+		tg.handleNeed(update.Message)
+		return
+	}
+
+	// Split a VPC between availability zones and tiers
+	if len(update.Message.Text) >= 8 && strings.ToLower(update.Message.Text[0:8]) == "/vpcplan" {
+		tg.handleVpcPlan(update.Message)
+		return
+	}
+
+	// Plan the networks of a Kubernetes cluster
+	if len(update.Message.Text) >= 8 && strings.ToLower(update.Message.Text[0:8]) == "/k8splan" {
+		tg.handleK8sPlan(update.Message)
+		return
+	}
+
+	// Generate the DHCP server configs of a network
+	if len(update.Message.Text) >= 5 && strings.ToLower(update.Message.Text[0:5]) == "/dhcp" {
+		tg.handleDhcp(update.Message)
+		return
+	}
+
+	// Generate the interface and routing config of a router
+	if len(update.Message.Text) >= 9 && strings.ToLower(update.Message.Text[0:9]) == "/ifconfig" {
+		tg.handleIfconfig(update.Message)
+		return
+	}
+
+	// Plan the IPv6 addressing of sites and VLANs
+	if len(update.Message.Text) >= 7 && strings.ToLower(update.Message.Text[0:7]) == "/v6plan" {
+		tg.handleV6Plan(update.Message)
+		return
+	}
+
+	// Plan paired IPv4 and IPv6 subnets for a list of VLANs
+	if len(update.Message.Text) >= 10 && strings.ToLower(update.Message.Text[0:10]) == "/dualstack" {
+		tg.handleDualStack(update.Message)
+		return
+	}
+
+	// Generate a Unique Local IPv6 prefix
+	if len(update.Message.Text) >= 4 && strings.ToLower(update.Message.Text[0:4]) == "/ula" {
+		tg.handleULA(update.Message)
+		return
+	}
+
+	// Pick random usable addresses of a network
+	if len(update.Message.Text) >= 7 && strings.ToLower(update.Message.Text[0:7]) == "/random" {
+		tg.handleRandom(update.Message)
+		return
+	}
+
+	// Manage the IPAM pools of the chat
+	if len(update.Message.Text) >= 5 && strings.ToLower(update.Message.Text[0:5]) == "/pool" {
+		tg.handlePool(update.Message)
+		return
+	}
+
+	if len(update.Message.Text) >= 6 && strings.ToLower(update.Message.Text[0:6]) == "/alloc" {
+		tg.handleAlloc(update.Message)
+		return
+	}
+
+	if len(update.Message.Text) >= 8 && strings.ToLower(update.Message.Text[0:8]) == "/release" {
+		tg.handleRelease(update.Message)
+		return
+	}
+
+	// Manage the reserved addresses of the chat
+	if len(update.Message.Text) >= 8 && strings.ToLower(update.Message.Text[0:8]) == "/reserve" {
+		tg.handleReserve(update.Message)
+		return
+	}
+
+	if len(update.Message.Text) >= 10 && strings.ToLower(update.Message.Text[0:10]) == "/unreserve" {
+		tg.handleUnreserve(update.Message)
+		return
+	}
+
+	if len(update.Message.Text) >= 7 && strings.ToLower(update.Message.Text[0:7]) == "/whohas" {
+		tg.handleWhoHas(update.Message)
+		return
+	}
+
+	if len(update.Message.Text) >= 5 && strings.ToLower(update.Message.Text[0:5]) == "/free" {
+		tg.handleFree(update.Message)
+		return
+	}
+
+	// Find the free blocks of a network around a list of allocated prefixes
+	if len(update.Message.Text) >= 9 && strings.ToLower(update.Message.Text[0:9]) == "/findfree" {
+		tg.handleFindFree(update.Message)
+		return
+	}
+
+	// Report the utilization of a network from a list of used addresses
+	if len(update.Message.Text) >= 6 && strings.ToLower(update.Message.Text[0:6]) == "/usage" {
+		tg.handleUsage(update.Message)
+		return
+	}
+
+	// Simulate the longest prefix match of a routing table
+	if len(update.Message.Text) >= 7 && strings.ToLower(update.Message.Text[0:7]) == "/routes" {
+		tg.handleRoutes(update.Message)
+		return
+	}
+
+	if len(update.Message.Text) >= 7 && strings.ToLower(update.Message.Text[0:7]) == "/lookup" {
+		tg.handleLookup(update.Message)
+		return
+	}
+
+	// Find the prefix sets, like the ranges of the cloud providers, containing an address
+	if len(update.Message.Text) >= 6 && strings.ToLower(update.Message.Text[0:6]) == "/inset" {
+		tg.handleInSet(update.Message)
+		return
+	}
+
+	if len(update.Message.Text) >= 5 && strings.ToLower(update.Message.Text[0:5]) == "/sets" {
+		tg.handleSets(update.Message)
+		return
+	}
+
+	// Calculate transfer times, rates, bandwidth-delay products and TCP windows
+	if len(update.Message.Text) >= 3 && strings.ToLower(update.Message.Text[0:3]) == "/bw" {
+		tg.handleBandwidth(update.Message)
+		return
+	}
+
+	// Calculate the MTU and MSS left by a stack of encapsulations
+	if len(update.Message.Text) >= 4 && strings.ToLower(update.Message.Text[0:4]) == "/mtu" {
+		tg.handleMtu(update.Message)
+		return
+	}
+
+	// Decode the headers of a packet written in hex
+	if len(update.Message.Text) >= 7 && strings.ToLower(update.Message.Text[0:7]) == "/decode" {
+		tg.handleDecode(update.Message)
+		return
+	}
+
+	// Split an IPv4 datagram in the fragments of an MTU
+	if len(update.Message.Text) >= 5 && strings.ToLower(update.Message.Text[0:5]) == "/frag" {
+		tg.handleFrag(update.Message)
+		return
+	}
+
+	// Query the records of a name, or the name of an address
+	if len(update.Message.Text) >= 4 && strings.ToLower(update.Message.Text[0:4]) == "/dig" {
+		tg.handleDig(update.Message)
+		return
+	}
+
+	// Find the registration of an address block, an autonomous system or a domain
+	if len(update.Message.Text) >= 5 && strings.ToLower(update.Message.Text[0:5]) == "/rdap" {
+		tg.handleRdap(update.Message)
+		return
+	}
+
+	// Reload the GeoIP and ASN databases, matched before /geo so the other users are refused instead of getting its usage
+	if len(update.Message.Text) >= 10 && strings.ToLower(update.Message.Text[0:10]) == "/georeload" {
+		text := "ERROR: only the admins can reload the GeoIP and ASN databases."
+		if tg.db.FindAdmin(int64(update.Message.From.ID)) >= 0 {
+			text = "GeoIP and ASN databases reloaded."
+			for _, err := range tg.geo.Reload() {
+				text += "\nERROR: " + err.Error() + "."
+			}
+			text += "\n" + tg.geo.Status()
+		}
+
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, text)
+		msg.ReplyToMessageID = update.Message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	// Find the location and the autonomous system of an address in the local databases
+	if len(update.Message.Text) >= 4 && strings.ToLower(update.Message.Text[0:4]) == "/geo" {
+		tg.handleGeo(update.Message)
+		return
+	}
+
+	if len(update.Message.Text) >= 4 && strings.ToLower(update.Message.Text[0:4]) == "/asn" {
+		tg.handleAsn(update.Message)
+		return
+	}
+
+	// Generate the regular expressions matching a prefix or a range
+	if len(update.Message.Text) >= 6 && strings.ToLower(update.Message.Text[0:6]) == "/regex" {
+		tg.handleRegex(update.Message)
+		return
+	}
+
+	// Clean up a messy list of addresses and prefixes
+	if len(update.Message.Text) >= 10 && strings.ToLower(update.Message.Text[0:10]) == "/normalize" {
+		tg.handleNormalize(update.Message)
+		return
+	}
+
+	// Calculate every network of a list or of a document
+	if len(update.Message.Text) >= 5 && strings.ToLower(update.Message.Text[0:5]) == "/bulk" {
+		tg.handleBulk(update.Message)
+		return
+	}
+
+	// Raw packets sent as .bin documents are decoded
+	if update.Message.Document != nil && update.Message.Chat.Type == "private" && !strings.HasPrefix(update.Message.Text, "/") && strings.HasSuffix(strings.ToLower(update.Message.Document.FileName), ".bin") {
+		tg.handleDecode(update.Message)
+		return
+	}
+
+	// Documents without a command are bulk lists too, only in private chats to avoid answering every file of a group
+	if update.Message.Document != nil && update.Message.Chat.Type == "private" && !strings.HasPrefix(update.Message.Text, "/") {
+		tg.handleBulk(update.Message)
+		return
+	}
+
 	// Calculate the network infos
 	if len(update.Message.Text) >= 5 && strings.ToLower(update.Message.Text[0:5]) == "/calc" {
 		// Split the message
@@ -247,8 +493,36 @@ func (tg *Telegram) HandleUpdate(update tgbotapi.Update) {
 				broadcast := network.ByteArrToStr(netInfo.Broadcast)
 				hostMinAddress := network.ByteArrToStr(netInfo.HostMinAddress)
 				hostMaxAddress := network.ByteArrToStr(netInfo.HostMaxAddress)
+				hostsQuantity := uint64(netInfo.HostsQuantity)
+
+				// Apply the reserved addresses of a cloud provider, if requested
+				var providerInfo string
+				if len(args) >= 4 {
+					provider, found := network.FindProvider(args[3])
+					if !found {
+						msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Unknown provider, use one of: aws, azure, gcp")
+						_, _ = tg.api.Send(msg)
+						return
+					}
+
+					hostMin, hostMax, quantity := provider.UsableRange(netInfo)
+					if hostMin == nil {
+						msg := tgbotapi.NewMessage(update.Message.Chat.ID, "This network has no usable addresses on "+provider.Name)
+						_, _ = tg.api.Send(msg)
+						return
+					}
+
+					hostMinAddress = network.ByteArrToStr(hostMin)
+					hostMaxAddress = network.ByteArrToStr(hostMax)
+					hostsQuantity = quantity
+
+					providerInfo = fmt.Sprintf("\nProvider: %s (%d reserved addresses)", provider.Name, provider.Reserved())
+					if err := provider.CheckPrefix(netInfo.Netmask.Decimal); err != nil {
+						providerInfo += "\nWARNING: " + err.Error()
+					}
+				}
 
-				msg := tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("Address: %s\nNetmask: %s\nWildcard: %s\nNetwork: %s\nBroadcast: %s\nHost Min Address: %s\nHost Max Address: %s\nHosts quantity: %d", args[1]+"/"+fmt.Sprint(netInfo.Netmask.Decimal), netmask, wildcard, networkAddr, broadcast, hostMinAddress, hostMaxAddress, netInfo.HostsQuantity))
+				msg := tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("Address: %s\nNetmask: %s\nWildcard: %s\nNetwork: %s\nBroadcast: %s\nHost Min Address: %s\nHost Max Address: %s\nHosts quantity: %d%s", args[1]+"/"+fmt.Sprint(netInfo.Netmask.Decimal), netmask, wildcard, networkAddr, broadcast, hostMinAddress, hostMaxAddress, hostsQuantity, providerInfo))
 				_, _ = tg.api.Send(msg)
 			} else {
 				msg := tgbotapi.NewMessage(update.Message.Chat.ID, "invalid parameters!")
@@ -265,6 +539,11 @@ func (tg *Telegram) HandleUpdate(update tgbotapi.Update) {
 
 	// Calculate the network infos and send a prettified output (might have a bad visualization for small devices)
 	if len(update.Message.Text) >= 6 && strings.ToLower(update.Message.Text[0:6]) == "/pcalc" {
+		return
+	}
 
+	// Find the addresses and prefixes of any other text sent in private chat, like forwarded logs, alerts or emails
+	if update.Message.Chat.Type == "private" && !strings.HasPrefix(update.Message.Text, "/") {
+		tg.handleExtract(update.Message)
 	}
 }
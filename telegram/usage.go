@@ -0,0 +1,93 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package telegram
+
+import (
+	"fmt"
+	"go-Telegram-NetworkCalculator-bot/network"
+	"net"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// joinAddresses returns the addresses separated by commas, with at most limit of them
+func joinAddresses(addresses []net.IP, limit int) string {
+	list := make([]string, 0, len(addresses))
+	for i, address := range addresses {
+		if i == limit {
+			list = append(list, fmt.Sprintf("… (%d more)", len(addresses)-limit))
+			break
+		}
+		list = append(list, address.String())
+	}
+
+	return strings.Join(list, ", ")
+}
+
+// handleUsage answers "/usage <cidr> [addresses...]" with the utilization of a network, reading the used addresses
+// from the message, the replied-to message and their documents (ARP tables, DHCP leases, plain lists...)
+func (tg *Telegram) handleUsage(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+	if len(args) < 2 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /usage <network>/<prefix> <used addresses...>\nThe used addresses can also be in the message you reply to or in a document, like an ARP table or a DHCP leases file.")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	// Skip the command and the network, the rest is the list of addresses
+	content, err := tg.messageContent(message)
+	content = textAfterFields(content, 2)
+	if err == nil && message.ReplyToMessage != nil {
+		var replyContent string
+		replyContent, err = tg.messageContent(message.ReplyToMessage)
+		content += "\n" + replyContent
+	}
+
+	var report network.UtilizationReport
+	if err == nil {
+		report, err = network.Utilization(args[1], network.ExtractAddresses(content))
+	}
+
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "ERROR: "+err.Error()+".")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	text := fmt.Sprintf("Network: %s\nUsed: %d of %s usable addresses (%.2f%%)\nFree ranges: %d", report.Network, report.Used, report.Usable, report.Percentage, len(report.Free))
+	for i, free := range report.Free {
+		if i == maxPlanLines {
+			text += "\n…"
+			break
+		}
+		text += "\n" + free.String()
+	}
+
+	if len(report.Reserved) > 0 {
+		text += "\n\nWARNING: reserved addresses in use: " + joinAddresses(report.Reserved, maxPlanLines)
+	}
+
+	if len(report.Outside) > 0 {
+		text += fmt.Sprintf("\n\nOutside of the network (%d): %s", len(report.Outside), joinAddresses(report.Outside, maxPlanLines))
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+}
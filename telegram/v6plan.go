@@ -0,0 +1,164 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package telegram
+
+import (
+	"fmt"
+	"go-Telegram-NetworkCalculator-bot/network"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Maximum quantity of lines of a plan shown in a message, the rest is available in the CSV
+const maxPlanLines = 60
+
+// Maximum quantity of sites or VLANs accepted by the planning commands
+const maxPlanItems = 4096
+
+// Highest usable VLAN ID, 0 and 4095 are reserved
+const maxVlanID = 4094
+
+// parseIDList parses a quantity (meaning IDs from 1 to quantity) or a comma separated list of VLAN IDs
+func parseIDList(str string) ([]uint64, error) {
+	ids := make([]uint64, 0)
+
+	if !strings.Contains(str, ",") {
+		quantity, err := strconv.ParseUint(str, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity %s", str)
+		}
+		if quantity > maxVlanID {
+			return nil, fmt.Errorf("no more than %d VLANs are supported", maxVlanID)
+		}
+
+		for id := uint64(1); id <= quantity; id++ {
+			ids = append(ids, id)
+		}
+		return ids, nil
+	}
+
+	idStrs := strings.Split(str, ",")
+	if len(idStrs) > maxVlanID {
+		return nil, fmt.Errorf("no more than %d VLANs are supported", maxVlanID)
+	}
+
+	for _, idStr := range idStrs {
+		id, err := strconv.ParseUint(idStr, 10, 12)
+		if err != nil || id == 0 || id > maxVlanID {
+			return nil, fmt.Errorf("invalid VLAN ID %s, use 1-%d", idStr, maxVlanID)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// handleV6Plan answers "/v6plan <prefix> [sites <n>] [vlans <n|list>] [vlanid] [csv]" with the sites and VLANs hierarchy
+func (tg *Telegram) handleV6Plan(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+	if len(args) < 2 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /v6plan <IPv6 network>/<prefix> [sites <quantity>] [vlans <quantity or comma separated IDs>] [vlanid] [csv]")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	// Parse the optional parameters
+	var sites uint64 = 1
+	vlans := make([]uint64, 0)
+	vlanIDs, csv := false, false
+	var err error
+	for i := 2; i < len(args) && err == nil; i++ {
+		switch strings.ToLower(args[i]) {
+		case "vlanid":
+			vlanIDs = true
+		case "csv":
+			csv = true
+		case "sites", "vlans":
+			if i+1 >= len(args) {
+				err = fmt.Errorf("missing value after \"%s\"", args[i])
+				break
+			}
+
+			if strings.ToLower(args[i]) == "sites" {
+				sites, err = strconv.ParseUint(args[i+1], 10, 64)
+				if err == nil && sites > maxPlanItems {
+					err = fmt.Errorf("no more than %d sites are supported", maxPlanItems)
+				}
+			} else {
+				vlans, err = parseIDList(args[i+1])
+			}
+			i++
+		default:
+			err = fmt.Errorf("unknown parameter %s", args[i])
+		}
+	}
+
+	if err == nil && sites*uint64(len(vlans)) > maxPlanItems*16 {
+		err = fmt.Errorf("no more than %d subnets are supported", maxPlanItems*16)
+	}
+
+	var plan network.V6Plan
+	if err == nil {
+		plan, err = network.PlanIPv6(args[1], sites, vlans, vlanIDs)
+	}
+
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "ERROR: "+err.Error()+".")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	// Capacity of every level
+	text := fmt.Sprintf("Aggregate: %s\nSites: /%d, %s fit in the aggregate\nVLANs: /64, %s fit in every site\n",
+		plan.Aggregate, plan.SiteLength, network.PowerOfTwo(plan.SiteBits), network.PowerOfTwo(plan.VlanBits))
+	if plan.VlanIDs {
+		text += "Subnet ID: VLAN ID digits, like :120: for VLAN 120\n"
+	}
+
+	// Hierarchy
+	lines := 0
+	text += "\n" + plan.Aggregate.String()
+hierarchy:
+	for _, site := range plan.Sites {
+		for v := -1; v < len(site.Vlans); v++ {
+			if lines == maxPlanLines {
+				text += "\n…"
+				break hierarchy
+			}
+
+			if v < 0 {
+				text += fmt.Sprintf("\n├ Site %d: %s", site.Index, site.Prefix)
+			} else {
+				text += fmt.Sprintf("\n│  ├ VLAN %d: %s", site.Vlans[v].ID, site.Vlans[v].Prefix)
+			}
+			lines++
+		}
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+
+	if csv {
+		document := tgbotapi.NewDocumentUpload(message.Chat.ID, tgbotapi.FileBytes{Name: "v6plan.csv", Bytes: []byte(plan.CSV())})
+		document.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(document)
+	}
+}
@@ -0,0 +1,157 @@
+/*
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package telegram
+
+import (
+	"fmt"
+	"go-Telegram-NetworkCalculator-bot/network"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Maximum quantity of tiers of a VPC plan
+const maxVpcTiers = 64
+
+// handleVpcPlan answers "/vpcplan <cidr> <azs> <tiers> [provider] [terraform]" with the subnets of every tier in every zone
+func (tg *Telegram) handleVpcPlan(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+	if len(args) < 4 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /vpcplan <network>/<prefix> <availability zones> <tiers quantity or names, comma separated> [aws|azure|gcp] [terraform]")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	zones, err := strconv.Atoi(args[2])
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Invalid availability zones quantity: "+args[2])
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	// Tiers can be a quantity or a list of names
+	var tiers []string
+	if tiersQuantity, err := strconv.Atoi(args[3]); err == nil {
+		if tiersQuantity > maxVpcTiers {
+			msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("ERROR: no more than %d tiers are supported.", maxVpcTiers))
+			msg.ReplyToMessageID = message.MessageID
+			_, _ = tg.api.Send(msg)
+			return
+		}
+
+		for i := 1; i <= tiersQuantity; i++ {
+			tiers = append(tiers, "tier"+strconv.Itoa(i))
+		}
+	} else {
+		for _, tier := range strings.Split(args[3], ",") {
+			if tier != "" {
+				tiers = append(tiers, tier)
+			}
+		}
+
+		if len(tiers) > maxVpcTiers {
+			msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("ERROR: no more than %d tiers are supported.", maxVpcTiers))
+			msg.ReplyToMessageID = message.MessageID
+			_, _ = tg.api.Send(msg)
+			return
+		}
+	}
+
+	// Parse the optional parameters
+	provider := network.Providers["aws"]
+	terraform := false
+	for _, arg := range args[4:] {
+		if cloudProvider, found := network.FindProvider(arg); found {
+			provider = cloudProvider
+		} else if strings.ToLower(arg) == "terraform" || strings.ToLower(arg) == "tf" {
+			terraform = true
+		} else {
+			msg := tgbotapi.NewMessage(message.Chat.ID, "Unknown parameter: "+arg)
+			msg.ReplyToMessageID = message.MessageID
+			_, _ = tg.api.Send(msg)
+			return
+		}
+	}
+
+	plan, err := network.PlanVpc(args[1], zones, tiers, provider)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "ERROR: "+err.Error()+".")
+		msg.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(msg)
+		return
+	}
+
+	text := fmt.Sprintf("VPC: %s/%d on %s (%d reserved addresses per subnet)\n", network.ByteArrToStr(plan.Network), plan.Netmask.Decimal, plan.Provider.Name, plan.Provider.Reserved())
+	for i, subnet := range plan.Subnets {
+		// Long plans are cut, the Terraform file has every subnet
+		if i == maxPlanLines {
+			text += "\n…"
+			break
+		}
+
+		text += fmt.Sprintf("\n%s-%s: %s/%d (%d usable)", subnet.Tier, network.ZoneName(subnet.Zone), network.ByteArrToStr(subnet.Network), subnet.Netmask.Decimal, subnet.Usable)
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	_, _ = tg.api.Send(msg)
+
+	if terraform {
+		document := tgbotapi.NewDocumentUpload(message.Chat.ID, tgbotapi.FileBytes{Name: "subnets.tf", Bytes: []byte(terraformSubnets(plan))})
+		document.ReplyToMessageID = message.MessageID
+		_, _ = tg.api.Send(document)
+	}
+}
+
+// terraformSubnets generates the Terraform HCL resources of every subnet in a plan
+func terraformSubnets(plan network.VpcPlan) string {
+	var hcl strings.Builder
+
+	for _, subnet := range plan.Subnets {
+		name := subnet.Tier + "-" + network.ZoneName(subnet.Zone)
+		resourceName := strings.NewReplacer("-", "_", ".", "_", " ", "_").Replace(name)
+		cidr := network.ByteArrToStr(subnet.Network) + "/" + strconv.Itoa(int(subnet.Netmask.Decimal))
+
+		switch plan.Provider.Name {
+		case "Azure":
+			fmt.Fprintf(&hcl, "resource \"azurerm_subnet\" %q {\n", resourceName)
+			fmt.Fprintf(&hcl, "  name                 = %q\n", name)
+			fmt.Fprintf(&hcl, "  resource_group_name  = var.resource_group_name\n")
+			fmt.Fprintf(&hcl, "  virtual_network_name = var.virtual_network_name\n")
+			fmt.Fprintf(&hcl, "  address_prefixes     = [%q]\n", cidr)
+			fmt.Fprintf(&hcl, "}\n\n")
+		case "GCP":
+			fmt.Fprintf(&hcl, "resource \"google_compute_subnetwork\" %q {\n", resourceName)
+			fmt.Fprintf(&hcl, "  name          = %q\n", name)
+			fmt.Fprintf(&hcl, "  network       = var.network\n")
+			fmt.Fprintf(&hcl, "  region        = var.region\n")
+			fmt.Fprintf(&hcl, "  ip_cidr_range = %q\n", cidr)
+			fmt.Fprintf(&hcl, "}\n\n")
+		default:
+			fmt.Fprintf(&hcl, "resource \"aws_subnet\" %q {\n", resourceName)
+			fmt.Fprintf(&hcl, "  vpc_id            = var.vpc_id\n")
+			fmt.Fprintf(&hcl, "  cidr_block        = %q\n", cidr)
+			fmt.Fprintf(&hcl, "  availability_zone = \"${var.region}%s\"\n\n", network.ZoneName(subnet.Zone))
+			fmt.Fprintf(&hcl, "  tags = {\n    Name = %q\n  }\n", name)
+			fmt.Fprintf(&hcl, "}\n\n")
+		}
+	}
+
+	return hcl.String()
+}